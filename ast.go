@@ -0,0 +1,183 @@
+package semver
+
+import (
+	"strings"
+)
+
+// RangeExpr is the syntax tree of a range as written, before it is desugared
+// into comparators. Its String method gives back the source exactly, so a
+// node can be edited and the range printed again without reformatting the
+// rest of it.
+type RangeExpr struct {
+	// Leading and Trailing hold whitespace around the range
+	Leading, Trailing string
+	Or                *OrNode
+	options           RangeOptions
+}
+
+// Node is an element of a range's syntax tree. Pos and End give the byte
+// span the node was parsed from; they are not updated when a node is edited.
+type Node interface {
+	Pos() int
+	End() int
+	String() string
+	desugar(options RangeOptions) (comparatorSet, *RangeParseError)
+}
+
+// OrNode is a list of alternatives separated by ||
+type OrNode struct {
+	Ranges []*AndNode
+	// Separators[i] is the text between Ranges[i] and Ranges[i+1],
+	// including the || and any whitespace around it
+	Separators []string
+	pos, end   int
+}
+
+// AndNode is a list of elements that must all match. An empty list matches
+// everything.
+type AndNode struct {
+	Nodes []Node
+	// Separators[i] is the whitespace between Nodes[i] and Nodes[i+1]
+	Separators []string
+	pos, end   int
+}
+
+// PrimitiveNode is an optional operator followed by a full version, like
+// >=1.2.3 or 1.2.3
+type PrimitiveNode struct {
+	// Op is one of "", "=", "<", "<=", ">" and ">="
+	Op string
+	// Space is the whitespace between Op and Version
+	Space   string
+	Version PartialVersion
+	pos     int
+}
+
+// XRangeNode is an optional operator followed by a version with missing or
+// wildcard components, like 1.2.x, >=1 or *
+type XRangeNode struct {
+	// Op is one of "", "=", "<", "<=", ">" and ">="
+	Op string
+	// Space is the whitespace between Op and Version
+	Space   string
+	Version PartialVersion
+	pos     int
+}
+
+// CaretNode is ^1.2.3
+type CaretNode struct {
+	// Space is the whitespace between ^ and Version
+	Space   string
+	Version PartialVersion
+	pos     int
+}
+
+// TildeNode is ~1.2.3 or ~>1.2.3
+type TildeNode struct {
+	// Op is "~" or "~>"
+	Op string
+	// Space is the whitespace between Op and Version
+	Space   string
+	Version PartialVersion
+	pos     int
+}
+
+// HyphenNode is an inclusive range like 1.2.3 - 2.3.4
+type HyphenNode struct {
+	From PartialVersion
+	// Hyphen is the text between From and To, such as " - "
+	Hyphen string
+	To     PartialVersion
+}
+
+// PartialVersion is a version as written in a range. Trailing components
+// may be missing or one of x, X and *.
+type PartialVersion struct {
+	Text string
+	pos  int
+	// scanned caches the parts of Text as it was when parsed
+	scanned *scannedVersion
+}
+
+// NewPartialVersion returns a version for use in an edited range
+func NewPartialVersion(text string) PartialVersion {
+	return PartialVersion{Text: text}
+}
+
+func (e *RangeExpr) String() string {
+	return e.Leading + e.Or.String() + e.Trailing
+}
+
+// Range desugars the expression into a Range, using the options it was
+// parsed with
+func (e *RangeExpr) Range() (*Range, error) {
+	set, err := e.Or.desugar(e.options)
+	if err != nil {
+		if err.Input == "" {
+			err.Input = e.String()
+		}
+		return nil, err
+	}
+	return &Range{set: set, options: e.options}, nil
+}
+
+func (n *OrNode) Pos() int { return n.pos }
+func (n *OrNode) End() int { return n.end }
+
+func (n *OrNode) String() string {
+	var b strings.Builder
+	for i, r := range n.Ranges {
+		if i > 0 {
+			b.WriteString(separator(n.Separators, i-1, " || "))
+		}
+		b.WriteString(r.String())
+	}
+	return b.String()
+}
+
+func (n *AndNode) Pos() int { return n.pos }
+func (n *AndNode) End() int { return n.end }
+
+func (n *AndNode) String() string {
+	var b strings.Builder
+	for i, node := range n.Nodes {
+		if i > 0 {
+			b.WriteString(separator(n.Separators, i-1, " "))
+		}
+		b.WriteString(node.String())
+	}
+	return b.String()
+}
+
+// separator returns the i'th separator as written, or def for one that was
+// added since
+func separator(separators []string, i int, def string) string {
+	if i < len(separators) {
+		return separators[i]
+	}
+	return def
+}
+
+func (n *PrimitiveNode) Pos() int       { return n.pos }
+func (n *PrimitiveNode) End() int       { return n.Version.End() }
+func (n *PrimitiveNode) String() string { return n.Op + n.Space + n.Version.Text }
+
+func (n *XRangeNode) Pos() int       { return n.pos }
+func (n *XRangeNode) End() int       { return n.Version.End() }
+func (n *XRangeNode) String() string { return n.Op + n.Space + n.Version.Text }
+
+func (n *CaretNode) Pos() int       { return n.pos }
+func (n *CaretNode) End() int       { return n.Version.End() }
+func (n *CaretNode) String() string { return "^" + n.Space + n.Version.Text }
+
+func (n *TildeNode) Pos() int       { return n.pos }
+func (n *TildeNode) End() int       { return n.Version.End() }
+func (n *TildeNode) String() string { return n.Op + n.Space + n.Version.Text }
+
+func (n *HyphenNode) Pos() int       { return n.From.Pos() }
+func (n *HyphenNode) End() int       { return n.To.End() }
+func (n *HyphenNode) String() string { return n.From.Text + n.Hyphen + n.To.Text }
+
+func (v PartialVersion) Pos() int       { return v.pos }
+func (v PartialVersion) End() int       { return v.pos + len(v.Text) }
+func (v PartialVersion) String() string { return v.Text }
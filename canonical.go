@@ -0,0 +1,124 @@
+package semver
+
+import (
+	"strings"
+)
+
+// Equal returns true if both ranges match exactly the same versions, however
+// they are spelled, so ^1.2.0 equals >=1.2.0 <2.0.0 and 1.x equals 1.*. The
+// ranges may differ in IncludePrerelease, as 1.2.3 matches the same versions
+// with or without it. Equal ranges have the same CanonicalString.
+func (r *Range) Equal(other *Range) bool {
+	return r.versions().equal(other.versions())
+}
+
+// emptyRange is how a range matching nothing is spelled
+const emptyRange = "<0.0.0-0"
+
+// CanonicalString returns the same string for every range that matches the
+// same versions, with or without IncludePrerelease, which makes it a stable
+// key for maps and caches. It is spelled for a range without
+// IncludePrerelease and parses back to an equal one. Only a range that
+// nothing but IncludePrerelease can spell, such as ^1.2 with it, is written
+// for IncludePrerelease instead; if all its bounds are releases, as with
+// >=1.2.3, that reads differently without IncludePrerelease, so key those
+// ranges together with the option.
+func (r *Range) CanonicalString() string {
+	s := r.versions()
+	windows, ok := s.windows()
+	o := []string{}
+	if !ok {
+		for _, i := range s.releases {
+			o = append(o, intervalString(i))
+		}
+	} else {
+		// the releases it allows, followed by the prereleases of each
+		// major.minor.patch it opts in to
+		releases := []Interval{}
+		for _, i := range s.releaseSpans() {
+			in, _ := releaseInterval(i)
+			releases = append(releases, in)
+		}
+		for _, i := range normalizeIntervals(releases) {
+			if i.Lower.Version != nil && i.Upper.Version != nil && nextRelease(i.Lower.Version, false).Version.EQ(i.Upper.Version) {
+				// a single release
+				i.Upper = Bound{Version: i.Lower.Version, Inclusive: true}
+			}
+			o = append(o, intervalString(i))
+		}
+		for _, i := range windows {
+			o = append(o, intervalString(i))
+		}
+	}
+	if len(o) == 0 {
+		return emptyRange
+	}
+	return strings.Join(o, " || ")
+}
+
+// releaseInterval narrows i to the releases inside it, with an inclusive
+// lower bound and an exclusive upper one so that equal sets of releases
+// always give equal intervals. It returns false if i holds no release.
+func releaseInterval(i Interval) (Interval, bool) {
+	lower := Bound{Version: release(0, 0, 0), Inclusive: true}
+	if v := i.Lower.Version; v != nil {
+		lower.Version = release(v.Major, v.Minor, v.Patch)
+		if len(v.Prerelease) == 0 && !i.Lower.Inclusive {
+			lower = nextRelease(v, false)
+		}
+	}
+	upper := Bound{}
+	if v := i.Upper.Version; v != nil {
+		upper = Bound{Version: release(v.Major, v.Minor, v.Patch)}
+		if len(v.Prerelease) == 0 && i.Upper.Inclusive {
+			upper = nextRelease(v, true)
+		}
+	}
+	o := Interval{Lower: lower, Upper: upper}
+	if o.empty() {
+		return o, false
+	}
+	if lower.Inclusive && lower.Version.EQ(release(0, 0, 0)) {
+		o.Lower = Bound{}
+	}
+	return o, true
+}
+
+// nextRelease moves a bound on the release v onto the release after it, so
+// >v becomes >=v+1 and <=v becomes <v+1. inclusive is the bound's own flag,
+// kept as it is when v has no successor.
+func nextRelease(v *Version, inclusive bool) Bound {
+	if v.Patch == maxComponent {
+		return Bound{Version: release(v.Major, v.Minor, v.Patch), Inclusive: inclusive}
+	}
+	return Bound{Version: release(v.Major, v.Minor, v.Patch+1), Inclusive: !inclusive}
+}
+
+// prereleaseWindow is the interval holding exactly the prereleases of t
+func prereleaseWindow(t [3]uint64) Interval {
+	i := Interval{
+		Lower: Bound{Version: windowStart(t), Inclusive: true},
+		Upper: Bound{Version: release(t[0], t[1], t[2])},
+	}
+	return i.canonical()
+}
+
+// intervalString prints i without build metadata, which does not change
+// what it matches
+func intervalString(i Interval) string {
+	for _, b := range []*Bound{&i.Lower, &i.Upper} {
+		if b.Version != nil {
+			b.Version = &Version{Major: b.Version.Major, Minor: b.Version.Minor, Patch: b.Version.Patch, Prerelease: b.Version.Prerelease, Build: []string{}}
+		}
+	}
+	return i.String()
+}
+
+// windowStart is the lowest prerelease of t
+func windowStart(t [3]uint64) *Version {
+	return &Version{Major: t[0], Minor: t[1], Patch: t[2], Prerelease: []string{"0"}, Build: []string{}}
+}
+
+func release(major, minor, patch uint64) *Version {
+	return &Version{Major: major, Minor: minor, Patch: patch, Prerelease: []string{}, Build: []string{}}
+}
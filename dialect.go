@@ -0,0 +1,574 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dialect picks the range syntax ParseRange reads. Every dialect desugars
+// into the same Range, so matching and set algebra work the same way.
+type Dialect int
+
+const (
+	// DialectNPM is node-semver syntax: ~, ^, x-ranges, hyphen ranges and ||
+	DialectNPM Dialect = iota
+	// DialectCargo is Cargo syntax: comma separated comparators, where a
+	// bare version means ^
+	DialectCargo
+	// DialectComposer is Composer syntax: ~1.2 means >=1.2.0 <2.0.0, a bare
+	// version means exactly that version, comparators are separated by
+	// spaces or commas and alternatives by || or |
+	DialectComposer
+	// DialectRuby is RubyGems syntax: comma separated comparators with the
+	// pessimistic ~> operator, where a bare version means exactly that version
+	DialectRuby
+	// DialectMaven is Maven interval notation such as [1.0,2.0) or
+	// (,1.0],[1.2,). A bare version means that version or later.
+	DialectMaven
+	// DialectPEP440 is Python style: comma separated comparators using ==,
+	// !=, ~= and the other comparisons, with == 1.2.* as a prefix match
+	DialectPEP440
+	// DialectGo is a Go module query: v1.2.3, a prefix such as v1.2, a
+	// comparison such as >=v1.2.3, or latest
+	DialectGo
+)
+
+var dialectNames = []string{"npm", "cargo", "composer", "ruby", "maven", "pep440", "go"}
+
+func (d Dialect) String() string {
+	if d >= 0 && int(d) < len(dialectNames) {
+		return dialectNames[d]
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// clauseSyntax describes a dialect made of comparator clauses such as
+// >=1.2 or ~>1.2.3
+type clauseSyntax struct {
+	operators []string
+	// or allows alternatives separated by ||, and pipe by a single | too
+	or, pipe bool
+	// commas and spaces say what may separate the clauses of an alternative
+	commas, spaces bool
+	hyphen         bool
+	// single allows only one clause
+	single  bool
+	desugar func(op string, v *scannedVersion) (comparatorSet, *RangeParseError)
+}
+
+var cargoSyntax = &clauseSyntax{
+	operators: []string{"", "=", "<", "<=", ">", ">=", "~", "^"},
+	commas:    true,
+	desugar:   desugarCargo,
+}
+
+var composerSyntax = &clauseSyntax{
+	operators: []string{"", "=", "==", "!=", "<", "<=", ">", ">=", "~", "^"},
+	or:        true,
+	pipe:      true,
+	commas:    true,
+	spaces:    true,
+	hyphen:    true,
+	desugar:   desugarComposer,
+}
+
+var rubySyntax = &clauseSyntax{
+	operators: []string{"", "=", "!=", "<", "<=", ">", ">=", "~>"},
+	commas:    true,
+	desugar:   desugarRuby,
+}
+
+var pep440Syntax = &clauseSyntax{
+	operators: []string{"==", "===", "!=", "<", "<=", ">", ">=", "~="},
+	commas:    true,
+	desugar:   desugarPEP440,
+}
+
+var goSyntax = &clauseSyntax{
+	operators: []string{"", "<", "<=", ">", ">="},
+	single:    true,
+	desugar:   desugarGo,
+}
+
+// parseDialect parses raw in one of the dialects other than npm
+func parseDialect(raw string, options RangeOptions) (comparatorSet, error) {
+	var syntax *clauseSyntax
+	switch options.Dialect {
+	case DialectMaven:
+		set, err := parseMaven(raw, options)
+		if err != nil {
+			err.Input = raw
+			return nil, err
+		}
+		return set, nil
+	case DialectCargo:
+		syntax = cargoSyntax
+	case DialectComposer:
+		syntax = composerSyntax
+	case DialectRuby:
+		syntax = rubySyntax
+	case DialectPEP440:
+		syntax = pep440Syntax
+	case DialectGo:
+		syntax = goSyntax
+	default:
+		return nil, fmt.Errorf("unknown range dialect: %s", options.Dialect)
+	}
+	p := &rangeParser{input: raw, tokens: lexClauses(raw, syntax), options: options}
+	set, err := p.parseAlternatives(syntax)
+	if err != nil {
+		err.Input = raw
+		return nil, err
+	}
+	return set, nil
+}
+
+// lexClauses splits a range into tokens like lexRange, adding commas and a
+// lone | where the dialect uses them
+func lexClauses(input string, syntax *clauseSyntax) []token {
+	tokens := []token{}
+	pos := 0
+	for pos < len(input) {
+		c := input[pos]
+		start := pos
+		switch {
+		case isASCIISpace(c):
+			pos++
+			continue
+		case syntax.or && strings.HasPrefix(input[pos:], "||"):
+			pos += 2
+			tokens = append(tokens, token{tokenOr, start, input[start:pos]})
+		case syntax.pipe && c == '|':
+			pos++
+			tokens = append(tokens, token{tokenOr, start, input[start:pos]})
+		case c == ',':
+			pos++
+			tokens = append(tokens, token{tokenComma, start, input[start:pos]})
+		case strings.IndexByte("<>=~^!", c) >= 0:
+			for pos < len(input) && strings.IndexByte("<>=~^!", input[pos]) >= 0 {
+				pos++
+			}
+			tokens = append(tokens, token{tokenOperator, start, input[start:pos]})
+		case syntax.hyphen && c == '-' && (pos+1 == len(input) || isASCIISpace(input[pos+1])):
+			pos++
+			tokens = append(tokens, token{tokenHyphen, start, input[start:pos]})
+		default:
+			pos++
+			for pos < len(input) && !isASCIISpace(input[pos]) && strings.IndexByte("|,", input[pos]) < 0 {
+				pos++
+			}
+			tokens = append(tokens, token{tokenVersion, start, input[start:pos]})
+		}
+	}
+	return append(tokens, token{tokenEOF, len(input), ""})
+}
+
+func (p *rangeParser) parseAlternatives(syntax *clauseSyntax) (comparatorSet, *RangeParseError) {
+	set := comparatorSet{}
+	for {
+		alternative, err := p.parseClauses(syntax)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, alternative...)
+		if p.advance().kind == tokenEOF {
+			return set, nil
+		}
+	}
+}
+
+func (p *rangeParser) parseClauses(syntax *clauseSyntax) (comparatorSet, *RangeParseError) {
+	set := newClauseSet()
+	for n := 0; ; n++ {
+		t := p.peek()
+		if t.kind == tokenEOF || t.kind == tokenOr {
+			if n == 0 {
+				return comparatorSet{{anyComparator()}}, nil
+			}
+			return set.comparators(), nil
+		}
+		if t.kind == tokenComma {
+			if n == 0 || !syntax.commas {
+				return nil, p.fail(t, ReasonInvalid)
+			}
+			p.advance()
+			if next := p.peek(); next.kind == tokenEOF || next.kind == tokenOr || next.kind == tokenComma {
+				return nil, p.fail(t, ReasonDanglingOperator)
+			}
+		} else if n > 0 && (syntax.single || !syntax.spaces) {
+			return nil, p.fail(t, ReasonInvalid)
+		}
+		start := p.peek()
+		clause, err := p.parseClause(syntax)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind == tokenHyphen && n == 0 && start.kind == tokenVersion {
+			// a hyphen range has to be the whole of its alternative
+			clause, err = p.parseClauseHyphen(start)
+			if err != nil {
+				return nil, err
+			}
+			if t := p.peek(); t.kind != tokenEOF && t.kind != tokenOr {
+				return nil, p.fail(t, ReasonInvalid)
+			}
+		}
+		set.intersect(clause)
+	}
+}
+
+// clauseSet is the intersection of the clauses read so far. It keeps each
+// alternative as its tightest bounds, written as they were so the prerelease
+// rule still sees them, and drops alternatives that match nothing. While
+// every clause has its alternatives in order and apart, as != gives, so do
+// the ones kept, and a clause costs a binary search per alternative rather
+// than a pass over all of them.
+type clauseSet struct {
+	alternatives []Interval
+	sorted       bool
+	// spare is the previous list, reused to build the next one
+	spare []Interval
+}
+
+func newClauseSet() *clauseSet {
+	return &clauseSet{alternatives: []Interval{{}}, sorted: true}
+}
+
+func (s *clauseSet) intersect(clause comparatorSet) {
+	b := []Interval{}
+	for _, y := range clause {
+		if i := y.bounds(); !i.canonical().empty() {
+			b = append(b, i)
+		}
+	}
+	o := []Interval{}
+	if !s.sorted || !apart(b) {
+		for _, x := range s.alternatives {
+			for _, y := range b {
+				if i := intersectInterval(x, y); !i.canonical().empty() {
+					o = append(o, i)
+				}
+			}
+		}
+		sort.SliceStable(o, func(m, n int) bool {
+			return compareLower(o[m].Lower, o[n].Lower) < 0
+		})
+		s.alternatives, s.sorted = o, apart(o)
+		return
+	}
+	a := s.alternatives
+	o = s.spare[:0]
+	for _, y := range b {
+		// the alternatives reaching into y, all but the first and last of
+		// which lie wholly inside it
+		lo := sort.Search(len(a), func(n int) bool {
+			return !intersectInterval(a[n], Interval{Lower: y.Lower}).canonical().empty()
+		})
+		hi := sort.Search(len(a), func(n int) bool {
+			return intersectInterval(a[n], Interval{Upper: y.Upper}).canonical().empty()
+		})
+		if lo == hi {
+			continue
+		}
+		if i := intersectInterval(a[lo], y); !i.canonical().empty() {
+			o = append(o, i)
+		}
+		if hi-lo > 1 {
+			o = append(o, a[lo+1:hi-1]...)
+			if i := intersectInterval(a[hi-1], y); !i.canonical().empty() {
+				o = append(o, i)
+			}
+		}
+	}
+	s.alternatives, s.spare = o, a
+}
+
+// apart returns true if the intervals are in order and no two overlap
+func apart(intervals []Interval) bool {
+	for n := 1; n < len(intervals); n++ {
+		if compareLower(intervals[n-1].Lower, intervals[n].Lower) > 0 || !intersectInterval(intervals[n-1], intervals[n]).canonical().empty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *clauseSet) comparators() comparatorSet {
+	return rangeFromIntervals(s.alternatives).set
+}
+
+func (p *rangeParser) parseClause(syntax *clauseSyntax) (comparatorSet, *RangeParseError) {
+	t := p.advance()
+	op := ""
+	if t.kind == tokenOperator {
+		if !containsString(syntax.operators, t.text) {
+			return nil, p.fail(t, ReasonBadOperator)
+		}
+		op = t.text
+		if p.peek().kind != tokenVersion {
+			return nil, p.fail(t, ReasonDanglingOperator)
+		}
+		t = p.advance()
+	}
+	if t.kind != tokenVersion {
+		return nil, p.fail(t, ReasonDanglingOperator)
+	}
+	if op == "" && !containsString(syntax.operators, "") {
+		// the dialect needs an operator in front of every version
+		return nil, p.fail(t, ReasonInvalid)
+	}
+	if syntax == goSyntax && op == "" && t.text == "latest" {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	v, err := p.parseVersion(t)
+	if err != nil {
+		return nil, err
+	}
+	return syntax.desugar(op, v.scanned)
+}
+
+func (p *rangeParser) parseClauseHyphen(start token) (comparatorSet, *RangeParseError) {
+	dash := p.advance()
+	if p.peek().kind != tokenVersion {
+		return nil, p.fail(dash, ReasonDanglingOperator)
+	}
+	from, err := p.parseVersion(start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := p.parseVersion(p.advance())
+	if err != nil {
+		return nil, err
+	}
+	return hyphen(from.scanned, to.scanned)
+}
+
+func desugarCargo(op string, v *scannedVersion) (comparatorSet, *RangeParseError) {
+	switch op {
+	case "", "^":
+		return v.caret()
+	case "~":
+		return v.tilde()
+	}
+	return v.xRange(op)
+}
+
+func desugarComposer(op string, v *scannedVersion) (comparatorSet, *RangeParseError) {
+	switch op {
+	case "~":
+		return v.pessimistic()
+	case "^":
+		return v.caret()
+	case "!=":
+		return v.notEqual()
+	case "==":
+		op = "="
+	}
+	if v.wildcard {
+		return v.xRange(op)
+	}
+	return comparatorSet{{newComparator(op, v.filled())}}, nil
+}
+
+func desugarRuby(op string, v *scannedVersion) (comparatorSet, *RangeParseError) {
+	if v.wildcard {
+		return nil, v.fail(ReasonBadCharacter)
+	}
+	switch op {
+	case "~>":
+		return v.pessimistic()
+	case "!=":
+		return v.notEqual()
+	}
+	return comparatorSet{{newComparator(op, v.filled())}}, nil
+}
+
+func desugarPEP440(op string, v *scannedVersion) (comparatorSet, *RangeParseError) {
+	switch op {
+	case "==", "===":
+		if v.wildcard {
+			return v.xRange("")
+		}
+		return comparatorSet{{newComparator("", v.filled())}}, nil
+	case "!=":
+		return v.notEqual()
+	case "~=":
+		if v.parts < 2 {
+			return nil, v.fail(ReasonMissingComponent)
+		}
+		return v.pessimistic()
+	}
+	if v.wildcard {
+		return nil, v.fail(ReasonBadCharacter)
+	}
+	return comparatorSet{{newComparator(op, v.filled())}}, nil
+}
+
+func desugarGo(op string, v *scannedVersion) (comparatorSet, *RangeParseError) {
+	if !strings.HasPrefix(v.text, "v") {
+		// module versions always carry their v
+		return nil, v.fail(ReasonInvalid)
+	}
+	return v.xRange(op)
+}
+
+// pessimistic desugars ~> in RubyGems, ~= in PEP 440 and ~ in Composer,
+// which let the last given component change: ~>1.2 is >=1.2.0 <2.0.0 and
+// ~>1.2.3 is >=1.2.3 <1.3.0
+func (v *scannedVersion) pessimistic() (comparatorSet, *RangeParseError) {
+	if v.parts == 0 {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	significant := v.parts - 1
+	if significant < 1 {
+		significant = 1
+	}
+	upper, err := v.bump(significant)
+	if err != nil {
+		return nil, err
+	}
+	return comparatorSet{{newComparator(">=", v.lower()), newComparator("<", upper)}}, nil
+}
+
+// notEqual matches every version except v, or every version outside a
+// wildcard prefix such as 1.2.*
+func (v *scannedVersion) notEqual() (comparatorSet, *RangeParseError) {
+	if !v.wildcard {
+		return comparatorSet{{newComparator("<", v.filled())}, {newComparator(">", v.filled())}}, nil
+	}
+	if v.parts == 0 {
+		return comparatorSet{{newComparator("<", minimumVersion)}}, nil
+	}
+	next, err := v.next()
+	if err != nil {
+		return nil, err
+	}
+	return comparatorSet{{newComparator("<", v.start(v.floor()))}, {newComparator(">=", next)}}, nil
+}
+
+// filled returns the version with missing components as 0, so 1.2 gives 1.2.0
+func (v *scannedVersion) filled() *Version {
+	if v.parts < 3 {
+		return v.floor()
+	}
+	return v.full()
+}
+
+func (v *scannedVersion) fail(reason Reason) *RangeParseError {
+	return &RangeParseError{Offset: v.pos, Token: v.text, Reason: reason}
+}
+
+// parseMaven reads Maven interval notation: a comma separated union of
+// [a,b], (a,b), [a] and half-open mixes, where a missing end is unbounded
+func parseMaven(raw string, options RangeOptions) (comparatorSet, *RangeParseError) {
+	p := &rangeParser{input: raw, options: options}
+	pos := 0
+	skipSpace := func() {
+		for pos < len(raw) && isASCIISpace(raw[pos]) {
+			pos++
+		}
+	}
+	// version reads a version up to one of the stop bytes, returning nil if
+	// there is none
+	version := func(stop string) (*scannedVersion, *RangeParseError) {
+		skipSpace()
+		start := pos
+		for pos < len(raw) && strings.IndexByte(stop, raw[pos]) < 0 {
+			pos++
+		}
+		end := pos
+		for end > start && isASCIISpace(raw[end-1]) {
+			end--
+		}
+		if start == end {
+			return nil, nil
+		}
+		v, err := p.parseVersion(token{tokenVersion, start, raw[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		if v.scanned.wildcard {
+			return nil, v.scanned.fail(ReasonBadCharacter)
+		}
+		return v.scanned, nil
+	}
+	fail := func(offset int, reason Reason) *RangeParseError {
+		return &RangeParseError{Offset: offset, Token: raw[offset:runeEnd(raw, offset, len(raw))], Reason: reason}
+	}
+	set := comparatorSet{}
+	skipSpace()
+	if pos == len(raw) {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	for {
+		skipSpace()
+		if pos == len(raw) || raw[pos] == ',' {
+			return nil, fail(pos, ReasonInvalid)
+		}
+		if raw[pos] != '[' && raw[pos] != '(' {
+			// a bare version is a soft requirement for that version or later
+			v, err := version(",")
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators{newComparator(">=", v.filled())})
+		} else {
+			open := pos
+			inclusive := raw[pos] == '['
+			pos++
+			lower, err := version(",])")
+			if err != nil {
+				return nil, err
+			}
+			if pos == len(raw) {
+				return nil, fail(open, ReasonDanglingOperator)
+			}
+			if raw[pos] != ',' {
+				// [1.2] is exactly 1.2
+				if lower == nil || !inclusive || raw[pos] != ']' {
+					return nil, fail(pos, ReasonInvalid)
+				}
+				pos++
+				set = append(set, comparators{newComparator("", lower.filled())})
+			} else {
+				pos++
+				upper, err := version("])")
+				if err != nil {
+					return nil, err
+				}
+				if pos == len(raw) {
+					return nil, fail(open, ReasonDanglingOperator)
+				}
+				c := comparators{}
+				if lower != nil {
+					op := ">"
+					if inclusive {
+						op = ">="
+					}
+					c = append(c, newComparator(op, lower.filled()))
+				}
+				if upper != nil {
+					op := "<"
+					if raw[pos] == ']' {
+						op = "<="
+					}
+					c = append(c, newComparator(op, upper.filled()))
+				}
+				if len(c) == 0 {
+					c = append(c, anyComparator())
+				}
+				pos++
+				set = append(set, c)
+			}
+		}
+		skipSpace()
+		if pos == len(raw) {
+			return set, nil
+		}
+		if raw[pos] != ',' {
+			return nil, fail(pos, ReasonBadCharacter)
+		}
+		pos++
+	}
+}
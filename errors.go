@@ -0,0 +1,71 @@
+package semver
+
+import (
+	"fmt"
+)
+
+// Reason says why a version or range failed to parse
+type Reason int
+
+const (
+	ReasonInvalid Reason = iota
+	ReasonLeadingZero
+	ReasonEmptyIdentifier
+	ReasonBadCharacter
+	ReasonMissingComponent
+	ReasonOverflow
+	ReasonBadOperator
+	ReasonDanglingOperator
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonLeadingZero:
+		return "leading zero"
+	case ReasonEmptyIdentifier:
+		return "empty identifier"
+	case ReasonBadCharacter:
+		return "bad character"
+	case ReasonMissingComponent:
+		return "missing component"
+	case ReasonOverflow:
+		return "number too large"
+	case ReasonBadOperator:
+		return "bad operator"
+	case ReasonDanglingOperator:
+		return "dangling operator"
+	}
+	return "invalid syntax"
+}
+
+// ParseError is returned when a version cannot be parsed. Offset is the byte
+// offset of Token within Input.
+type ParseError struct {
+	Input  string
+	Offset int
+	Token  string
+	Reason Reason
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid version: %s: %s %q at offset %d", e.Input, e.Reason, e.Token, e.Offset)
+}
+
+// RangeParseError is returned when a range cannot be parsed. Offset is the
+// byte offset of Token within Input. Err holds the *ParseError when the
+// problem is inside one of the range's versions.
+type RangeParseError struct {
+	Input  string
+	Offset int
+	Token  string
+	Reason Reason
+	Err    error
+}
+
+func (e *RangeParseError) Error() string {
+	return fmt.Sprintf("invalid range: %s: %s %q at offset %d", e.Input, e.Reason, e.Token, e.Offset)
+}
+
+func (e *RangeParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,203 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReleaseType names a level of change between two versions
+type ReleaseType string
+
+const (
+	Major      ReleaseType = "major"
+	Premajor   ReleaseType = "premajor"
+	Minor      ReleaseType = "minor"
+	Preminor   ReleaseType = "preminor"
+	Patch      ReleaseType = "patch"
+	Prepatch   ReleaseType = "prepatch"
+	Prerelease ReleaseType = "prerelease"
+)
+
+// Wildcard is what Diff returns when only one side is the wildcard, which is
+// no particular version and so differs at no level. Inc does not take it.
+const Wildcard ReleaseType = "wildcard"
+
+// Inc returns the next version of the given kind: major, premajor, minor,
+// preminor, patch, prepatch or prerelease. preid names the prerelease
+// identifier to use, such as "beta" for 1.2.4-beta.0. Build metadata is
+// dropped and the receiver is left untouched. It fails if a component would
+// go past the largest number a version can hold, for the wildcard, which
+// has no next version, or if preid is not a valid prerelease.
+func (this *Version) Inc(kind, preid string) (*Version, error) {
+	if this.empty {
+		return nil, fmt.Errorf("invalid increment: %s of the wildcard", kind)
+	}
+	var ids []string
+	if preid != "" {
+		ids = strings.Split(preid, ".")
+		if err := checkIdentifiers(ids, true); err != nil {
+			return nil, fmt.Errorf("invalid increment: %w", err)
+		}
+	}
+	v := &Version{
+		Major:      this.Major,
+		Minor:      this.Minor,
+		Patch:      this.Patch,
+		Prerelease: append([]string{}, this.Prerelease...),
+		Build:      []string{},
+	}
+	var ok bool
+	switch ReleaseType(kind) {
+	case Major:
+		ok = v.incMajor()
+	case Premajor:
+		v.Prerelease = []string{}
+		v.Patch = 0
+		v.Minor = 0
+		ok = increment(&v.Major)
+		v.incPre(ids)
+	case Minor:
+		ok = v.incMinor()
+	case Preminor:
+		v.Prerelease = []string{}
+		v.Patch = 0
+		ok = increment(&v.Minor)
+		v.incPre(ids)
+	case Patch:
+		ok = v.incPatch()
+	case Prepatch:
+		v.Prerelease = []string{}
+		ok = v.incPatch()
+		v.incPre(ids)
+	case Prerelease:
+		ok = true
+		if len(v.Prerelease) == 0 {
+			ok = v.incPatch()
+		}
+		v.incPre(ids)
+	default:
+		return nil, fmt.Errorf("invalid increment: %s", kind)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid increment: %s of %s overflows", kind, this)
+	}
+	return v, nil
+}
+
+// IncMajor returns the next major version, 1.2.3 becomes 2.0.0. Like the
+// other shorthands it returns nil if the version cannot be incremented.
+func (this *Version) IncMajor() *Version {
+	v, _ := this.Inc(string(Major), "")
+	return v
+}
+
+// IncMinor returns the next minor version, 1.2.3 becomes 1.3.0
+func (this *Version) IncMinor() *Version {
+	v, _ := this.Inc(string(Minor), "")
+	return v
+}
+
+// IncPatch returns the next patch version, 1.2.3 becomes 1.2.4 and
+// 1.2.3-rc.1 becomes 1.2.3
+func (this *Version) IncPatch() *Version {
+	v, _ := this.Inc(string(Patch), "")
+	return v
+}
+
+// IncPrerelease returns the next prerelease, 1.2.3-rc.1 becomes 1.2.3-rc.2
+// and 1.2.3 becomes 1.2.4-<preid>.0
+func (this *Version) IncPrerelease(preid string) *Version {
+	v, _ := this.Inc(string(Prerelease), preid)
+	return v
+}
+
+func (v *Version) incMajor() bool {
+	ok := true
+	// 1.0.0-5 bumps to 1.0.0 rather than 2.0.0
+	if v.Minor != 0 || v.Patch != 0 || len(v.Prerelease) == 0 {
+		ok = increment(&v.Major)
+	}
+	v.Minor = 0
+	v.Patch = 0
+	v.Prerelease = []string{}
+	return ok
+}
+
+func (v *Version) incMinor() bool {
+	ok := true
+	// 1.2.0-5 bumps to 1.2.0 rather than 1.3.0
+	if v.Patch != 0 || len(v.Prerelease) == 0 {
+		ok = increment(&v.Minor)
+	}
+	v.Patch = 0
+	v.Prerelease = []string{}
+	return ok
+}
+
+func (v *Version) incPatch() bool {
+	ok := true
+	// 1.2.3-5 bumps to 1.2.3 rather than 1.2.4
+	if len(v.Prerelease) == 0 {
+		ok = increment(&v.Patch)
+	}
+	v.Prerelease = []string{}
+	return ok
+}
+
+// increment adds one to n unless that would overflow
+func increment(n *uint64) bool {
+	if *n == maxComponent {
+		return false
+	}
+	*n++
+	return true
+}
+
+// incPre bumps the last numeric prerelease identifier, appending a 0 if
+// there is none. A different preid restarts the prerelease at <preid>.0.
+func (v *Version) incPre(preid []string) {
+	bumped := false
+	for i := len(v.Prerelease) - 1; i >= 0; i-- {
+		if isNumeric(v.Prerelease[i]) {
+			v.Prerelease[i] = incrementDigits(v.Prerelease[i])
+			bumped = true
+			break
+		}
+	}
+	if !bumped {
+		v.Prerelease = append(v.Prerelease, "0")
+	}
+	if len(preid) == 0 {
+		return
+	}
+	if len(v.Prerelease) <= len(preid) || !isNumeric(v.Prerelease[len(preid)]) || !equalIdentifiers(v.Prerelease[:len(preid)], preid) {
+		v.Prerelease = append(append([]string{}, preid...), "0")
+	}
+}
+
+// equalIdentifiers returns true if a and b hold the same identifiers in order
+func equalIdentifiers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// incrementDigits adds one to a decimal string without converting it to an
+// int, so arbitrarily long numeric identifiers cannot overflow.
+func incrementDigits(s string) string {
+	b := []byte(s)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] != '9' {
+			b[i]++
+			return string(b)
+		}
+		b[i] = '0'
+	}
+	return "1" + string(b)
+}
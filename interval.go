@@ -0,0 +1,568 @@
+package semver
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Bound is one end of an Interval. A nil Version means the interval is
+// unbounded on that side.
+type Bound struct {
+	Version   *Version
+	Inclusive bool
+}
+
+// Interval is a contiguous span of versions between Lower and Upper. When
+// ReleasesOnly is set it holds only the releases in that span, which is how a
+// range without IncludePrerelease matches outside the prereleases it names.
+type Interval struct {
+	Lower        Bound
+	Upper        Bound
+	ReleasesOnly bool
+}
+
+var minimumVersion = &Version{Major: 0, Minor: 0, Patch: 0, Prerelease: []string{"0"}, Build: []string{}}
+
+// predecessor returns the version immediately before v if there is one.
+// 1.2.4-0 follows 1.2.3 and 1.2.3-beta.0 follows 1.2.3-beta, nothing else
+// has a direct predecessor.
+func predecessor(v *Version) *Version {
+	n := len(v.Prerelease)
+	if n == 0 || v.Prerelease[n-1] != "0" {
+		return nil
+	}
+	if n == 1 {
+		if v.Patch == 0 {
+			return nil
+		}
+		return &Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch - 1, Prerelease: []string{}, Build: []string{}}
+	}
+	pre := make([]string, n-1)
+	copy(pre, v.Prerelease)
+	return &Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: pre, Build: []string{}}
+}
+
+// canonical rewrites bounds sitting on a successor version so equal sets
+// always have equal bounds: >=1.2.4-0 becomes >1.2.3 and <1.2.4-0 becomes <=1.2.3.
+func (i Interval) canonical() Interval {
+	if i.Lower.Version != nil && i.Lower.Inclusive {
+		if p := predecessor(i.Lower.Version); p != nil {
+			i.Lower = Bound{Version: p}
+		}
+	}
+	if i.Upper.Version != nil && !i.Upper.Inclusive {
+		if p := predecessor(i.Upper.Version); p != nil {
+			i.Upper = Bound{Version: p, Inclusive: true}
+		}
+	}
+	return i
+}
+
+// candidates lists the lowest versions that sit on or just above a lower
+// bound, smallest first
+func (b Bound) candidates() []*Version {
+	if b.Version == nil {
+		return []*Version{{Prerelease: []string{"0"}, Build: []string{}}, {Prerelease: []string{}, Build: []string{}}}
+	}
+	v := &Version{Major: b.Version.Major, Minor: b.Version.Minor, Patch: b.Version.Patch, Prerelease: append([]string{}, b.Version.Prerelease...), Build: []string{}}
+	if b.Inclusive {
+		return []*Version{v}
+	}
+	if len(v.Prerelease) > 0 {
+		v.Prerelease = append(v.Prerelease, "0")
+		return []*Version{v}
+	}
+	// the next release, carrying into minor and major when patch is at its limit
+	if !increment(&v.Patch) {
+		v.Patch = 0
+		if !increment(&v.Minor) {
+			v.Minor = 0
+			if !increment(&v.Major) {
+				return []*Version{}
+			}
+		}
+	}
+	return []*Version{{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: []string{"0"}, Build: []string{}}, v}
+}
+
+func compareLower(a, b Bound) int {
+	if a.Version == nil || b.Version == nil {
+		return compare(boolToInt(b.Version == nil), boolToInt(a.Version == nil))
+	}
+	if c := a.Version.compare(b.Version); c != 0 {
+		return c
+	}
+	return compare(boolToInt(b.Inclusive), boolToInt(a.Inclusive))
+}
+
+func compareUpper(a, b Bound) int {
+	if a.Version == nil || b.Version == nil {
+		return compare(boolToInt(a.Version == nil), boolToInt(b.Version == nil))
+	}
+	if c := a.Version.compare(b.Version); c != 0 {
+		return c
+	}
+	return compare(boolToInt(a.Inclusive), boolToInt(b.Inclusive))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (i Interval) empty() bool {
+	if i.Upper.Version != nil && !i.Upper.Inclusive && i.Upper.Version.EQ(minimumVersion) {
+		return true
+	}
+	if i.Lower.Version == nil || i.Upper.Version == nil {
+		return false
+	}
+	c := i.Lower.Version.compare(i.Upper.Version)
+	return c > 0 || c == 0 && !(i.Lower.Inclusive && i.Upper.Inclusive)
+}
+
+func intersectInterval(a, b Interval) Interval {
+	o := a
+	if compareLower(b.Lower, a.Lower) > 0 {
+		o.Lower = b.Lower
+	}
+	if compareUpper(b.Upper, a.Upper) < 0 {
+		o.Upper = b.Upper
+	}
+	return o
+}
+
+func (c *comparator) interval() Interval {
+	return c.bounds().canonical()
+}
+
+// bounds is the interval of c with its version kept as written, which
+// matters to the prerelease rule
+func (c *comparator) bounds() Interval {
+	i := Interval{}
+	if c.version.empty {
+		return i
+	}
+	if c.eq {
+		i.Lower = Bound{Version: c.version, Inclusive: true}
+		i.Upper = Bound{Version: c.version, Inclusive: true}
+	} else if c.gt {
+		i.Lower = Bound{Version: c.version}
+	} else if c.gte {
+		i.Lower = Bound{Version: c.version, Inclusive: true}
+	} else if c.lt {
+		i.Upper = Bound{Version: c.version}
+	} else if c.lte {
+		i.Upper = Bound{Version: c.version, Inclusive: true}
+	}
+	return i
+}
+
+func (comparators comparators) interval() Interval {
+	return comparators.bounds().canonical()
+}
+
+// bounds is the tightest lower and upper bound among the comparators, as
+// written. A comparator naming a prerelease is only dropped for a tighter
+// bound, which either names a prerelease of the same major.minor.patch or
+// leaves none of them inside, so the comparators of the result match the
+// same versions.
+func (comparators comparators) bounds() Interval {
+	i := Interval{}
+	for _, c := range comparators {
+		i = intersectInterval(i, c.bounds())
+	}
+	return i
+}
+
+// normalizeIntervals drops empty intervals, sorts the rest and merges any
+// that overlap or touch so the result is a list of disjoint spans.
+func normalizeIntervals(input []Interval) []Interval {
+	intervals := []Interval{}
+	for _, i := range input {
+		if !i.empty() {
+			intervals = append(intervals, i)
+		}
+	}
+	sort.SliceStable(intervals, func(a, b int) bool {
+		return compareLower(intervals[a].Lower, intervals[b].Lower) < 0
+	})
+	o := []Interval{}
+	for _, i := range intervals {
+		if len(o) == 0 {
+			o = append(o, i)
+			continue
+		}
+		last := &o[len(o)-1]
+		if !touches(*last, i) {
+			o = append(o, i)
+			continue
+		}
+		if compareUpper(i.Upper, last.Upper) > 0 {
+			last.Upper = i.Upper
+		}
+	}
+	return o
+}
+
+// touches reports whether b, which starts no earlier than a, overlaps or
+// directly continues a.
+func touches(a, b Interval) bool {
+	if a.Upper.Version == nil || b.Lower.Version == nil {
+		return true
+	}
+	c := b.Lower.Version.compare(a.Upper.Version)
+	return c < 0 || c == 0 && (b.Lower.Inclusive || a.Upper.Inclusive)
+}
+
+// complementIntervals returns the gaps between normalized intervals.
+func complementIntervals(intervals []Interval) []Interval {
+	o := []Interval{}
+	next := Interval{}
+	for _, i := range intervals {
+		if i.Lower.Version != nil {
+			next.Upper = Bound{Version: i.Lower.Version, Inclusive: !i.Lower.Inclusive}
+			o = append(o, next)
+		}
+		if i.Upper.Version == nil {
+			return normalizeIntervals(o)
+		}
+		next = Interval{Lower: Bound{Version: i.Upper.Version, Inclusive: !i.Upper.Inclusive}}
+	}
+	return normalizeIntervals(append(o, next))
+}
+
+func intersectIntervals(a, b []Interval) []Interval {
+	o := []Interval{}
+	for _, x := range a {
+		for _, y := range b {
+			o = append(o, intersectInterval(x, y))
+		}
+	}
+	return normalizeIntervals(o)
+}
+
+// versionSet is what a range matches: the releases inside releases and the
+// prereleases inside prereleases, both normalized. Without IncludePrerelease
+// a comparator set only adds the prerelease windows of the major.minor.patch
+// tuples it names; with it both lists are the same.
+type versionSet struct {
+	releases, prereleases []Interval
+}
+
+func (r *Range) versions() versionSet {
+	s := versionSet{}
+	for _, comparators := range r.set {
+		i := comparators.interval()
+		s.releases = append(s.releases, i)
+		if r.options.IncludePrerelease {
+			s.prereleases = append(s.prereleases, i)
+			continue
+		}
+		for _, c := range comparators {
+			if c.version.empty || len(c.version.Prerelease) == 0 {
+				continue
+			}
+			t := [3]uint64{c.version.Major, c.version.Minor, c.version.Patch}
+			s.prereleases = append(s.prereleases, intersectInterval(i, prereleaseWindow(t)))
+		}
+	}
+	return versionSet{normalizeIntervals(s.releases), normalizeIntervals(s.prereleases)}
+}
+
+func (s versionSet) intersect(o versionSet) versionSet {
+	return versionSet{intersectIntervals(s.releases, o.releases), intersectIntervals(s.prereleases, o.prereleases)}
+}
+
+func (s versionSet) union(o versionSet) versionSet {
+	return versionSet{
+		normalizeIntervals(append(append([]Interval{}, s.releases...), o.releases...)),
+		normalizeIntervals(append(append([]Interval{}, s.prereleases...), o.prereleases...)),
+	}
+}
+
+func (s versionSet) complement() versionSet {
+	return versionSet{complementIntervals(s.releases), complementIntervals(s.prereleases)}
+}
+
+func (s versionSet) isEmpty() bool {
+	return len(s.releaseSpans()) == 0 && len(s.prereleaseSpans()) == 0
+}
+
+// uniform returns true if the releases intervals hold exactly the
+// prereleases s matches, so that a range with IncludePrerelease can be
+// spelled from them
+func (s versionSet) uniform() bool {
+	return sameIntervals(versionSet{prereleases: s.releases}.prereleaseParts(), s.prereleaseParts())
+}
+
+// releaseSpans returns the intervals holding the releases of s, with any
+// bound on a prerelease moved onto a release. No comparator spelling them
+// names a prerelease, so without IncludePrerelease they match releases only.
+func (s versionSet) releaseSpans() []Interval {
+	o := []Interval{}
+	for _, i := range s.releases {
+		if v := i.Lower.Version; v != nil && len(v.Prerelease) > 0 {
+			i.Lower = Bound{Version: release(v.Major, v.Minor, v.Patch), Inclusive: true}
+		}
+		if v := i.Upper.Version; v != nil && len(v.Prerelease) > 0 {
+			i.Upper = Bound{Version: release(v.Major, v.Minor, v.Patch)}
+		}
+		if _, ok := releaseInterval(i); ok {
+			o = append(o, i)
+		}
+	}
+	return normalizeIntervals(o)
+}
+
+// prereleaseSpans returns the intervals holding the prereleases of s. Their
+// bounds leave out releases, so every interval holds at least one prerelease.
+func (s versionSet) prereleaseSpans() []Interval {
+	o := []Interval{}
+	for _, i := range s.prereleases {
+		i = i.canonical()
+		for _, b := range []*Bound{&i.Lower, &i.Upper} {
+			if b.Version != nil && len(b.Version.Prerelease) == 0 {
+				b.Inclusive = false
+			}
+		}
+		o = append(o, i)
+	}
+	return normalizeIntervals(o)
+}
+
+// prereleaseParts joins prereleaseSpans across gaps holding a single
+// release, so equal sets of prereleases always give equal intervals
+func (s versionSet) prereleaseParts() []Interval {
+	o := []Interval{}
+	for _, i := range s.prereleaseSpans() {
+		if n := len(o); n > 0 {
+			last := &o[n-1]
+			if u := last.Upper.Version; u != nil && len(u.Prerelease) == 0 && u.EQ(i.Lower.Version) {
+				last.Upper = i.Upper
+				continue
+			}
+		}
+		o = append(o, i)
+	}
+	return o
+}
+
+// equal returns true if s and o hold the same versions, however their
+// intervals are split
+func (s versionSet) equal(o versionSet) bool {
+	a, b := []Interval{}, []Interval{}
+	for _, i := range s.releaseSpans() {
+		in, _ := releaseInterval(i)
+		a = append(a, in)
+	}
+	for _, i := range o.releaseSpans() {
+		in, _ := releaseInterval(i)
+		b = append(b, in)
+	}
+	return sameIntervals(normalizeIntervals(a), normalizeIntervals(b)) && sameIntervals(s.prereleaseParts(), o.prereleaseParts())
+}
+
+func sameIntervals(a, b []Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if compareLower(a[n].Lower, b[n].Lower) != 0 || compareUpper(a[n].Upper, b[n].Upper) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// maxWindows caps how many prerelease windows windows will list for one
+// span before giving up on spelling it without IncludePrerelease
+const maxWindows = 1 << 10
+
+// windows splits the prereleases of s into the windows of the
+// major.minor.patch tuples they belong to, each with a lower bound naming a
+// prerelease of its tuple, which is how a range without IncludePrerelease
+// matches them. It returns false if they span too many windows to list.
+func (s versionSet) windows() ([]Interval, bool) {
+	o := []Interval{}
+	for _, i := range s.prereleaseSpans() {
+		if i.Lower.Version == nil || i.Upper.Version == nil {
+			return nil, false
+		}
+		l, u := i.Lower.Version, i.Upper.Version
+		t := [3]uint64{l.Major, l.Minor, l.Patch}
+		if len(l.Prerelease) == 0 && !increment(&t[2]) {
+			return nil, false
+		}
+		if t[0] != u.Major || t[1] != u.Minor || u.Patch < t[2] || u.Patch-t[2] >= maxWindows {
+			return nil, false
+		}
+		for {
+			w := intersectInterval(i, prereleaseWindow(t))
+			if !w.canonical().empty() {
+				if len(w.Lower.Version.Prerelease) == 0 {
+					w.Lower = Bound{Version: windowStart(t), Inclusive: true}
+				}
+				o = append(o, w)
+			}
+			if t[2] == u.Patch {
+				break
+			}
+			t[2]++
+		}
+	}
+	return o, true
+}
+
+// Intervals returns the versions allowed by r as a list of intervals sorted
+// by their lower bound. Redundant comparators are folded away, so >=1.0.0
+// >=1.2.0 <2.0.0 yields the single interval [1.2.0, 2.0.0). If r matches
+// every prerelease inside its intervals, as it does with IncludePrerelease,
+// they are disjoint. Otherwise its releases come as disjoint ReleasesOnly
+// intervals and each prerelease window it opts in to as a disjoint interval
+// of its own, but a window can overlap a ReleasesOnly interval: the releases
+// of ^1.0.0 || 1.5.0-beta are [1.0.0, 2.0.0) and 1.5.0-beta lies inside it,
+// since no bound between releases could leave it out without naming a
+// prerelease.
+func (r *Range) Intervals() []Interval {
+	s := r.versions()
+	if s.uniform() {
+		return s.releases
+	}
+	o := []Interval{}
+	for _, i := range s.releaseSpans() {
+		i.ReleasesOnly = true
+		o = append(o, i)
+	}
+	windows, _ := s.windows()
+	o = append(o, windows...)
+	sort.SliceStable(o, func(a, b int) bool {
+		return compareLower(o[a].Lower, o[b].Lower) < 0
+	})
+	return o
+}
+
+func (i Interval) comparators() comparators {
+	if i.Lower.Version == nil && i.Upper.Version == nil {
+		return comparators{{version: &Version{empty: true}}}
+	}
+	if i.Lower.Version != nil && i.Upper.Version != nil && i.Lower.Inclusive && i.Upper.Inclusive && i.Lower.Version.EQ(i.Upper.Version) {
+		return comparators{{eq: true, version: i.Lower.Version}}
+	}
+	o := comparators{}
+	if i.Lower.Version != nil {
+		o = append(o, &comparator{gte: i.Lower.Inclusive, gt: !i.Lower.Inclusive, version: i.Lower.Version})
+	}
+	if i.Upper.Version != nil {
+		o = append(o, &comparator{lte: i.Upper.Inclusive, lt: !i.Upper.Inclusive, version: i.Upper.Version})
+	}
+	return o
+}
+
+// String prints the interval as comparators. It does not record
+// ReleasesOnly, since the comparators of those never name a prerelease.
+func (i Interval) String() string {
+	var o []string
+	for _, c := range i.comparators() {
+		o = append(o, c.String())
+	}
+	return strings.Join(o, " ")
+}
+
+// ErrMixedPrerelease is returned when a result would need IncludePrerelease
+// for some of its versions but not for others, which no single range can do
+var ErrMixedPrerelease = errors.New("invalid range: needs IncludePrerelease for only some versions")
+
+// NewRange builds a Range matching any version inside one of the intervals.
+// It uses IncludePrerelease only if the intervals need it, and fails with
+// ErrMixedPrerelease if only some of them do.
+func NewRange(intervals ...Interval) (*Range, error) {
+	s := versionSet{}
+	for _, i := range intervals {
+		i = i.canonical()
+		s.releases = append(s.releases, Interval{Lower: i.Lower, Upper: i.Upper})
+		if !i.ReleasesOnly {
+			s.prereleases = append(s.prereleases, Interval{Lower: i.Lower, Upper: i.Upper})
+		}
+	}
+	return rangeFromSet(versionSet{normalizeIntervals(s.releases), normalizeIntervals(s.prereleases)}, RangeOptions{})
+}
+
+// rangeFromSet builds a Range matching exactly s. It keeps options unless
+// only the other IncludePrerelease setting can spell s.
+func rangeFromSet(s versionSet, options RangeOptions) (*Range, error) {
+	for _, includePrerelease := range []bool{options.IncludePrerelease, !options.IncludePrerelease} {
+		options.IncludePrerelease = includePrerelease
+		if includePrerelease {
+			if s.uniform() {
+				r := rangeFromIntervals(s.releases)
+				r.options = options
+				return r, nil
+			}
+			continue
+		}
+		if windows, ok := s.windows(); ok {
+			r := rangeFromIntervals(append(s.releaseSpans(), windows...))
+			r.options = options
+			return r, nil
+		}
+	}
+	return nil, ErrMixedPrerelease
+}
+
+// rangeFromIntervals builds a Range with a comparator set for each interval.
+// A range with no intervals is spelled <0.0.0-0, which nothing satisfies.
+func rangeFromIntervals(intervals []Interval) *Range {
+	r := &Range{set: comparatorSet{}}
+	if len(intervals) == 0 {
+		r.set = append(r.set, comparators{{lt: true, version: minimumVersion}})
+		return r
+	}
+	for _, i := range intervals {
+		r.set = append(r.set, i.comparators())
+	}
+	return r
+}
+
+// IsEmpty returns true if no version can satisfy the range
+func (r *Range) IsEmpty() bool {
+	return r.versions().isEmpty()
+}
+
+// Intersect returns a range matching versions allowed by both a and b. It
+// has a's options, with IncludePrerelease only if both use it. Like Union and
+// Subtract it returns ErrMixedPrerelease if the result cannot be spelled,
+// though every prerelease in it comes from the windows of one side or both
+// sides include every prerelease, so that should not happen.
+func Intersect(a, b *Range) (*Range, error) {
+	options := a.options
+	options.IncludePrerelease = a.options.IncludePrerelease && b.options.IncludePrerelease
+	return rangeFromSet(a.versions().intersect(b.versions()), options)
+}
+
+// Union returns a range matching versions allowed by either a or b. It has
+// a's options unless only the other IncludePrerelease setting can spell the
+// result, and fails with ErrMixedPrerelease if neither can.
+func Union(a, b *Range) (*Range, error) {
+	return rangeFromSet(a.versions().union(b.versions()), a.options)
+}
+
+// Subtract returns a range matching versions allowed by a but not by b. It
+// has a's options unless only the other IncludePrerelease setting can spell
+// the result, and fails with ErrMixedPrerelease if neither can.
+func Subtract(a, b *Range) (*Range, error) {
+	return rangeFromSet(a.versions().intersect(b.versions().complement()), a.options)
+}
+
+// IsSubsetOf returns true if every version allowed by r is also allowed by other
+func (r *Range) IsSubsetOf(other *Range) bool {
+	return r.versions().intersect(other.versions().complement()).isEmpty()
+}
+
+// Intersects returns true if at least one version is allowed by both ranges
+func (r *Range) Intersects(other *Range) bool {
+	return !r.versions().intersect(other.versions()).isEmpty()
+}
@@ -0,0 +1,161 @@
+package semver
+
+// Matcher is a Range compiled for matching many versions. It gives the same
+// answers as Range.Valid without allocating or re-examining the range's
+// own prerelease identifiers on every call.
+type Matcher struct {
+	sets              []compiledSet
+	includePrerelease bool
+}
+
+type compiledSet struct {
+	// empty is set when the comparators contradict each other
+	empty        bool
+	lower, upper compiledBound
+	// prereleases lists the major.minor.patch tuples whose prereleases
+	// this set opts in to
+	prereleases [][3]uint64
+}
+
+// compiledBound is one end of a set's interval, unbounded if version is false
+type compiledBound struct {
+	version             bool
+	inclusive           bool
+	major, minor, patch uint64
+	pre                 []compiledIdentifier
+}
+
+type compiledIdentifier struct {
+	value   string
+	numeric bool
+}
+
+// Compile turns the range into a Matcher. Each comparator set is folded
+// into a single interval so matching costs at most two comparisons per set.
+func (r *Range) Compile() *Matcher {
+	m := &Matcher{includePrerelease: r.options.IncludePrerelease}
+	for _, comparators := range r.set {
+		i := comparators.interval()
+		set := compiledSet{empty: i.empty(), lower: compileBound(i.Lower), upper: compileBound(i.Upper)}
+		for _, c := range comparators {
+			if !c.version.empty && len(c.version.Prerelease) > 0 {
+				set.prereleases = append(set.prereleases, [3]uint64{c.version.Major, c.version.Minor, c.version.Patch})
+			}
+		}
+		m.sets = append(m.sets, set)
+	}
+	return m
+}
+
+func compileBound(b Bound) compiledBound {
+	if b.Version == nil {
+		return compiledBound{}
+	}
+	cb := compiledBound{version: true, inclusive: b.Inclusive, major: b.Version.Major, minor: b.Version.Minor, patch: b.Version.Patch}
+	for _, id := range b.Version.Prerelease {
+		cb.pre = append(cb.pre, compiledIdentifier{value: id, numeric: isNumeric(id)})
+	}
+	return cb
+}
+
+// Valid returns true if v satisfies the compiled range
+func (m *Matcher) Valid(v *Version) bool {
+	if v.empty {
+		return false
+	}
+	for i := range m.sets {
+		if m.sets[i].valid(v, m.includePrerelease) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the versions in input that satisfy the compiled range, in
+// their original order
+func (m *Matcher) Filter(input Versions) Versions {
+	o := Versions{}
+	for _, i := range input {
+		if m.Valid(i) {
+			o = append(o, i)
+		}
+	}
+	return o
+}
+
+func (set *compiledSet) valid(v *Version, includePrerelease bool) bool {
+	if set.empty {
+		return false
+	}
+	if set.lower.version {
+		c := set.lower.compare(v)
+		if c < 0 || c == 0 && !set.lower.inclusive {
+			return false
+		}
+	}
+	if set.upper.version {
+		c := set.upper.compare(v)
+		if c > 0 || c == 0 && !set.upper.inclusive {
+			return false
+		}
+	}
+	if len(v.Prerelease) == 0 || includePrerelease {
+		return true
+	}
+	for _, t := range set.prereleases {
+		if t[0] == v.Major && t[1] == v.Minor && t[2] == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares v against the bound's version
+func (b *compiledBound) compare(v *Version) int {
+	if v.Major != b.major {
+		return compare(v.Major, b.major)
+	}
+	if v.Minor != b.minor {
+		return compare(v.Minor, b.minor)
+	}
+	if v.Patch != b.patch {
+		return compare(v.Patch, b.patch)
+	}
+	if len(v.Prerelease) == 0 && len(b.pre) == 0 {
+		return 0
+	}
+	if len(v.Prerelease) > 0 && len(b.pre) == 0 {
+		return -1
+	} else if len(v.Prerelease) == 0 && len(b.pre) > 0 {
+		return 1
+	}
+	for i := 0; ; i++ {
+		if len(v.Prerelease) == i && len(b.pre) == i {
+			return 0
+		}
+		if len(v.Prerelease) == i {
+			return -1
+		}
+		if len(b.pre) == i {
+			return 1
+		}
+		a, id := v.Prerelease[i], b.pre[i]
+		if a == id.value {
+			continue
+		}
+		anum := isNumeric(a)
+		if anum && !id.numeric {
+			return -1
+		}
+		if !anum && id.numeric {
+			return 1
+		}
+		if anum {
+			return compareDigits(a, id.value)
+		}
+		if a < id.value {
+			return -1
+		}
+		return 1
+	}
+}
@@ -0,0 +1,296 @@
+package semver
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// text is anything a version can be scanned from without converting it first
+type text interface {
+	~string | ~[]byte
+}
+
+// maxComponent is the largest major, minor or patch number
+const maxComponent = ^uint64(0)
+
+// versionParts holds the numbers of a scanned version along with where its
+// prerelease and build identifiers sit in the input. Empty spans mean the
+// version has none. parts counts the leading components that are numbers
+// rather than wildcards or missing, so it is always 3 outside partial mode,
+// and wildcard is set if any component was written as x, X or *. padded is
+// set if a numeric prerelease identifier has leading zeros, which only loose
+// mode accepts.
+type versionParts struct {
+	major, minor, patch uint64
+	parts               int
+	wildcard            bool
+	padded              bool
+	pre, build          [2]int
+}
+
+// scanMode relaxes what scanVersion accepts
+type scanMode uint8
+
+const (
+	// scanPartial accepts x, X and * components and a missing minor or
+	// patch, as found in ranges
+	scanPartial scanMode = 1 << iota
+	// scanLoose accepts leading zeros and a prerelease without its hyphen
+	scanLoose
+)
+
+// scanVersion reads a SemVer version from input[start:end]
+func scanVersion[T text](input T, start, end int, mode scanMode) (versionParts, *ParseError) {
+	var o versionParts
+	pos := start
+	fail := func(reason Reason, tokenEnd int) *ParseError {
+		return &ParseError{Input: string(input[:end]), Offset: pos, Token: string(input[pos:tokenEnd]), Reason: reason}
+	}
+	failChar := func(reason Reason) *ParseError {
+		return fail(reason, runeEnd(input, pos, end))
+	}
+	if pos < end && input[pos] == 'v' {
+		pos++
+	}
+	partial := mode&scanPartial != 0
+	loose := mode&scanLoose != 0
+	nums := [3]*uint64{&o.major, &o.minor, &o.patch}
+	for i, n := range nums {
+		if i > 0 {
+			if pos == end && partial {
+				return o, nil
+			}
+			if pos == end {
+				return o, fail(ReasonMissingComponent, pos)
+			}
+			if input[pos] != '.' {
+				return o, failChar(ReasonBadCharacter)
+			}
+			pos++
+		}
+		if partial && pos < end && (input[pos] == 'x' || input[pos] == 'X' || input[pos] == '*') {
+			pos++
+			o.wildcard = true
+			continue
+		}
+		digits := pos
+		for digits < end && isDigit(input[digits]) {
+			if *n > (maxComponent-uint64(input[digits]-'0'))/10 {
+				for digits < end && isDigit(input[digits]) {
+					digits++
+				}
+				return o, fail(ReasonOverflow, digits)
+			}
+			*n = *n*10 + uint64(input[digits]-'0')
+			digits++
+		}
+		if digits == pos {
+			if pos == end {
+				return o, fail(ReasonMissingComponent, pos)
+			}
+			if input[pos] == '.' {
+				return o, fail(ReasonEmptyIdentifier, pos)
+			}
+			return o, failChar(ReasonBadCharacter)
+		}
+		if digits-pos > 1 && input[pos] == '0' && !loose {
+			return o, fail(ReasonLeadingZero, digits)
+		}
+		pos = digits
+		if !o.wildcard {
+			o.parts++
+		}
+	}
+	// identifiers scans dot separated prerelease or build identifiers
+	identifiers := func(prerelease bool) *ParseError {
+		for {
+			next := pos
+			numeric := true
+			for next < end && isIdentifierChar(input[next]) {
+				numeric = numeric && isDigit(input[next])
+				next++
+			}
+			if next == pos {
+				if pos == end || input[pos] == '.' || input[pos] == '+' {
+					return fail(ReasonEmptyIdentifier, pos)
+				}
+				return failChar(ReasonBadCharacter)
+			}
+			if prerelease && numeric && next-pos > 1 && input[pos] == '0' {
+				if !loose {
+					return fail(ReasonLeadingZero, next)
+				}
+				o.padded = true
+			}
+			pos = next
+			if pos == end || input[pos] != '.' {
+				return nil
+			}
+			pos++
+		}
+	}
+	if pos < end && (input[pos] == '-' || loose && isIdentifierChar(input[pos])) {
+		if input[pos] == '-' {
+			pos++
+		}
+		o.pre[0] = pos
+		if err := identifiers(true); err != nil {
+			return o, err
+		}
+		o.pre[1] = pos
+	}
+	if pos < end && input[pos] == '+' {
+		pos++
+		o.build[0] = pos
+		if err := identifiers(false); err != nil {
+			return o, err
+		}
+		o.build[1] = pos
+	}
+	if pos < end {
+		return o, failChar(ReasonBadCharacter)
+	}
+	return o, nil
+}
+
+// parse reads a whole version from raw. Blank input is the wildcard, and
+// in loose mode any run of v, = and spaces in front is skipped.
+func parse[T text](raw T, mode scanMode) (*Version, error) {
+	start, end := trimSpace(raw)
+	if start == end {
+		return &Version{empty: true}, nil
+	}
+	for mode&scanLoose != 0 && start < end && (raw[start] == 'v' || raw[start] == '=' || isASCIISpace(raw[start])) {
+		start++
+	}
+	parts, err := scanVersion(raw, start, end, mode)
+	if err != nil {
+		err.Input = string(raw)
+		return nil, err
+	}
+	return &Version{
+		Major:      parts.major,
+		Minor:      parts.minor,
+		Patch:      parts.patch,
+		Prerelease: scannedPrerelease(raw, parts),
+		Build:      splitIdentifiers(raw, parts.build),
+	}, nil
+}
+
+// splitIdentifiers splits a dot separated span of input with a single
+// string conversion
+func splitIdentifiers[T text](input T, span [2]int) []string {
+	if span[0] == span[1] {
+		return []string{}
+	}
+	s := string(input[span[0]:span[1]])
+	n := 1
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' {
+			n++
+		}
+	}
+	o := make([]string, 0, n)
+	last := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' {
+			o = append(o, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(o, s[last:])
+}
+
+// scannedPrerelease returns the prerelease identifiers of a scanned version.
+// Leading zeros loose mode let through are dropped, as node-semver does, so
+// the version prints as strict SemVer.
+func scannedPrerelease[T text](input T, parts versionParts) []string {
+	o := splitIdentifiers(input, parts.pre)
+	if !parts.padded {
+		return o
+	}
+	for i, id := range o {
+		if isNumeric(id) {
+			o[i] = strings.TrimLeft(id, "0")
+			if o[i] == "" {
+				o[i] = "0"
+			}
+		}
+	}
+	return o
+}
+
+// trimSpace returns the bounds of raw without surrounding whitespace, as
+// strings.TrimSpace would
+func trimSpace[T text](raw T) (int, int) {
+	start, end := 0, len(raw)
+	for start < end {
+		c := raw[start]
+		if c < utf8.RuneSelf {
+			if !isASCIISpace(c) {
+				break
+			}
+			start++
+			continue
+		}
+		tail := start + utf8.UTFMax
+		if tail > end {
+			tail = end
+		}
+		r, size := utf8.DecodeRuneInString(string(raw[start:tail]))
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	for end > start {
+		c := raw[end-1]
+		if c < utf8.RuneSelf {
+			if !isASCIISpace(c) {
+				break
+			}
+			end--
+			continue
+		}
+		head := end - utf8.UTFMax
+		if head < start {
+			head = start
+		}
+		r, size := utf8.DecodeLastRuneInString(string(raw[head:end]))
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	return start, end
+}
+
+// runeEnd returns where the character at input[pos] ends, so a token cut
+// out of the input never splits a multi-byte UTF-8 sequence
+func runeEnd[T text](input T, pos, end int) int {
+	if pos >= end {
+		return pos
+	}
+	if input[pos] < utf8.RuneSelf {
+		return pos + 1
+	}
+	tail := pos + utf8.UTFMax
+	if tail > end {
+		tail = end
+	}
+	_, size := utf8.DecodeRuneInString(string(input[pos:tail]))
+	return pos + size
+}
+
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierChar(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-'
+}
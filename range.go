@@ -3,101 +3,68 @@ package semver
 import (
 	"bytes"
 	"encoding/json"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 )
 
 type comparators []*comparator
 type comparatorSet []comparators
 type Range struct {
-	set comparatorSet
+	set     comparatorSet
+	options RangeOptions
 }
 
-func MustParseRange(raw string) *Range {
-	r, err := ParseRange(raw)
+// RangeOptions changes how ParseRange reads a range and how the result
+// matches versions
+type RangeOptions struct {
+	// IncludePrerelease lets prereleases match any comparator. By default a
+	// prerelease only matches if the range names a prerelease of the same
+	// major.minor.patch, so ^1.2.3 does not match 1.9.0-alpha. Like
+	// node-semver, the bounds a range desugars to then start at -0, so ^1.2
+	// is >=1.2.0-0 <2.0.0-0 and leaves out 2.0.0-alpha.
+	IncludePrerelease bool
+	// Loose accepts versions that are not strict SemVer, as ParseLoose does
+	Loose bool
+	// Dialect is the range syntax to read, npm by default
+	Dialect Dialect
+}
+
+// lastOptions returns the last of opts, which wins over the others
+func lastOptions(opts []RangeOptions) RangeOptions {
+	var options RangeOptions
+	for _, o := range opts {
+		options = o
+	}
+	return options
+}
+
+func MustParseRange(raw string, opts ...RangeOptions) *Range {
+	r, err := ParseRange(raw, opts...)
 	must(err)
 	return r
 }
 
-func atoi(s string) int {
-	i, err := strconv.Atoi(s)
-	must(err)
-	return i
-}
-func itoa(i int) string {
-	return strconv.Itoa(i)
-}
-
-func isX(id string) bool {
-	return id == "" || strings.ToLower(id) == "x" || id == "*"
-}
-
-func ParseRange(raw string) (*Range, error) {
-	raw = reHyphenRange.ReplaceAllStringFunc(raw, func(raw string) string {
-		submatches := reHyphenRange.FindStringSubmatch(raw)
-		from := submatches[1]
-		fM := submatches[2]
-		fm := submatches[3]
-		fp := submatches[4]
-		// fpr := submatches[5]
-		// fb := submatches[6]
-		to := submatches[7]
-		tM := submatches[8]
-		tm := submatches[9]
-		tp := submatches[10]
-		tpr := submatches[11]
-		// tb := submatches[12]
-		if isX(fM) {
-			from = ""
-		} else if isX(fm) {
-			from = ">=" + fM + ".0.0"
-		} else if isX(fp) {
-			from = ">=" + fM + "." + fm + ".0"
-		} else {
-			from = ">=" + from
-		}
-		if isX(tM) {
-			to = ""
-		} else if isX(tm) {
-			to = "<" + itoa((atoi(tM) + 1)) + ".0.0"
-		} else if isX(tp) {
-			to = "<" + tM + "." + itoa(atoi(tm)+1) + ".0"
-		} else if tpr != "" {
-			to = "<=" + tM + "." + tm + "." + tp + "-" + tpr
-		} else {
-			to = "<=" + to
+func ParseRange(raw string, opts ...RangeOptions) (*Range, error) {
+	if options := lastOptions(opts); options.Dialect != DialectNPM {
+		set, err := parseDialect(raw, options)
+		if err != nil {
+			return nil, err
 		}
-		return strings.TrimSpace(from + " " + to)
-	})
-	raw = reComparatorTrim.ReplaceAllString(raw, "$1$2$3")
-	raw = reTildeTrim.ReplaceAllString(raw, "$1~")
-	raw = reCaretTrim.ReplaceAllString(raw, "$1^")
-	raw = strings.Join(regexp.MustCompile(`\s+`).Split(raw, -1), " ")
-	raw = replaceTildes(raw)
-	raw = replaceCarets(raw)
-	raw = replaceXRanges(raw)
-	raw = replaceStars(raw)
-	r := &Range{
-		set: comparatorSet{},
-	}
-	for _, raw := range regexp.MustCompile(`\s*\|\|\s*`).Split(raw, -1) {
-		comparators := []*comparator{}
-		for _, raw := range strings.Split(raw, " ") {
-			c, err := parseComparator(raw)
-			if err != nil {
-				return nil, err
-			}
-			comparators = append(comparators, c)
-		}
-		r.set = append(r.set, comparators)
+		return &Range{set: set, options: options}, nil
+	}
+	expr, err := ParseRangeExpr(raw, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return r, nil
+	return expr.Range()
 }
 
+// Valid returns true if v satisfies the range. The wildcard stands for no
+// particular version, so it satisfies none.
 func (r *Range) Valid(v *Version) bool {
-	return r.set.Valid(v)
+	if v.empty {
+		return false
+	}
+	return r.set.valid(v, r.options.IncludePrerelease)
 }
 
 func (r *Range) String() string {
@@ -112,22 +79,35 @@ func (r *Range) String() string {
 	return strings.Join(i, " || ")
 }
 
-func (set comparatorSet) Valid(v *Version) bool {
+func (set comparatorSet) valid(v *Version, includePrerelease bool) bool {
 	for _, comparators := range set {
-		if comparators.Valid(v) {
+		if comparators.valid(v, includePrerelease) {
 			return true
 		}
 	}
 	return false
 }
 
-func (comparators comparators) Valid(v *Version) bool {
+func (comparators comparators) valid(v *Version, includePrerelease bool) bool {
 	for _, c := range comparators {
 		if !c.valid(v) {
 			return false
 		}
 	}
-	return true
+	if len(v.Prerelease) == 0 || includePrerelease {
+		return true
+	}
+	// a prerelease only matches if one of the comparators opted in to
+	// prereleases of the same major.minor.patch
+	for _, c := range comparators {
+		if c.version.empty || len(c.version.Prerelease) == 0 {
+			continue
+		}
+		if c.version.compareMajor(v) == 0 && c.version.compareMinor(v) == 0 && c.version.comparePatch(v) == 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func (this *Range) MarshalJSON() ([]byte, error) {
@@ -139,28 +119,124 @@ func (this *Range) MarshalJSON() ([]byte, error) {
 }
 
 func (this *Range) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	return this.UnmarshalText([]byte(raw))
+}
+
+// MarshalText writes the range as String does. The text does not record
+// IncludePrerelease, so a range parsed with it reads back without it and no
+// longer matches prereleases its comparators do not name: ^1.2 with
+// IncludePrerelease matches 1.5.0-beta, but the >=1.2.0-0 <2.0.0-0 it writes
+// does not. Keep the options alongside the text if they matter.
+func (this *Range) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+// UnmarshalText reads an npm range with the default RangeOptions
+func (this *Range) UnmarshalText(b []byte) error {
 	v, err := ParseRange(string(b))
 	if err != nil {
 		return err
 	}
-	this.set = v.set
+	*this = *v
 	return nil
 }
 
-// func (this *Range) GT(v *Version) bool {
-// 	return true
-// }
+// Direction picks which side of a range Outside looks at
+type Direction int
+
+const (
+	Above Direction = iota
+	Below
+)
+
+// Outside returns true if v is above (or below) every version the range
+// allows. A version inside a gap between two parts of the range is neither,
+// and so is the wildcard.
+func Outside(v *Version, r *Range, direction Direction) bool {
+	if v.empty || r.Valid(v) {
+		return false
+	}
+	s := r.versions()
+	if s.isEmpty() {
+		return false
+	}
+	// v is outside when nothing the range allows lies on its far side
+	side := Interval{Lower: Bound{Version: v, Inclusive: true}}
+	if direction == Below {
+		side = Interval{Upper: Bound{Version: v, Inclusive: true}}
+	}
+	return s.intersect(versionSet{[]Interval{side}, []Interval{side}}).isEmpty()
+}
+
+// GTR returns true if v is greater than any version the range allows
+func (this *Range) GTR(v *Version) bool {
+	return Outside(v, this, Above)
+}
+
+// LTR returns true if v is less than any version the range allows
+func (this *Range) LTR(v *Version) bool {
+	return Outside(v, this, Below)
+}
 
 func (this *Range) MaxSatisfying(input Versions) *Version {
-	v := Versions{}
+	var max *Version
+	for _, i := range input {
+		if (max == nil || i.GT(max)) && this.Valid(i) {
+			max = i
+		}
+	}
+	return max
+}
+
+// MinSatisfying returns the lowest version in input that satisfies the range
+func (this *Range) MinSatisfying(input Versions) *Version {
+	var min *Version
+	for _, i := range input {
+		if (min == nil || i.LT(min)) && this.Valid(i) {
+			min = i
+		}
+	}
+	return min
+}
+
+// Filter returns the versions in input that satisfy the range, in their
+// original order
+func (this *Range) Filter(input Versions) Versions {
+	o := Versions{}
 	for _, i := range input {
 		if this.Valid(i) {
-			v = append(v, i)
+			o = append(o, i)
 		}
 	}
-	sort.Sort(v)
-	if len(v) == 0 {
-		return nil
+	return o
+}
+
+// MinVersion returns the lowest version that could ever satisfy the range,
+// or nil if nothing can. Like node-semver it takes the lowest version each
+// comparator set allows on its own, since whether a prerelease matches
+// depends on the set it is in.
+func (this *Range) MinVersion() *Version {
+	var min *Version
+	for _, comparators := range this.set {
+		i := comparators.interval()
+		if i.empty() {
+			continue
+		}
+		for _, v := range i.Lower.candidates() {
+			if comparators.valid(v, this.options.IncludePrerelease) {
+				if min == nil || v.LT(min) {
+					min = v
+				}
+				break
+			}
+		}
 	}
-	return v[len(v)-1]
+	return min
 }
@@ -0,0 +1,551 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenOr
+	tokenHyphen
+	tokenOperator
+	tokenVersion
+	tokenComma
+)
+
+// token is a lexeme of a range. Whitespace separates tokens and is not kept.
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+var rangeOperators = []string{"<", ">", "<=", ">=", "=", "~", "~>", "^"}
+var primitiveOperators = []string{"", "=", "<", "<=", ">", ">="}
+
+// lexRange splits a range into tokens, ending with a tokenEOF
+func lexRange(input string) []token {
+	tokens := []token{}
+	pos := 0
+	for pos < len(input) {
+		c := input[pos]
+		start := pos
+		switch {
+		case isASCIISpace(c):
+			pos++
+			continue
+		case strings.HasPrefix(input[pos:], "||"):
+			pos += 2
+			tokens = append(tokens, token{tokenOr, start, input[start:pos]})
+		case strings.IndexByte("<>=~^", c) >= 0:
+			for pos < len(input) && strings.IndexByte("<>=~^", input[pos]) >= 0 {
+				pos++
+			}
+			tokens = append(tokens, token{tokenOperator, start, input[start:pos]})
+		case c == '-' && (pos+1 == len(input) || isASCIISpace(input[pos+1])):
+			pos++
+			tokens = append(tokens, token{tokenHyphen, start, input[start:pos]})
+		default:
+			// anything else runs to the next space or || and is left for
+			// scanVersion to judge, so a stray | is reported as a bad character
+			pos++
+			for pos < len(input) && !isASCIISpace(input[pos]) && input[pos] != '|' {
+				pos++
+			}
+			tokens = append(tokens, token{tokenVersion, start, input[start:pos]})
+		}
+	}
+	return append(tokens, token{tokenEOF, len(input), ""})
+}
+
+// rangeParser is a recursive-descent parser over the tokens of a range:
+//
+//	range     = and ( "||" and )*
+//	and       = hyphen | simple*
+//	hyphen    = version "-" version
+//	simple    = [ operator ] version
+type rangeParser struct {
+	input   string
+	tokens  []token
+	next    int
+	options RangeOptions
+}
+
+// ParseRangeExpr parses a range into its syntax tree without desugaring it
+func ParseRangeExpr(raw string, opts ...RangeOptions) (*RangeExpr, error) {
+	options := lastOptions(opts)
+	if options.Dialect != DialectNPM {
+		return nil, fmt.Errorf("range syntax trees are only available for the npm dialect, not %s", options.Dialect)
+	}
+	p := &rangeParser{input: raw, tokens: lexRange(raw), options: options}
+	or, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return &RangeExpr{Leading: raw[:or.pos], Trailing: raw[or.end:], Or: or, options: options}, nil
+}
+
+func (p *rangeParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *rangeParser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *rangeParser) fail(t token, reason Reason) *RangeParseError {
+	return &RangeParseError{Input: p.input, Offset: t.pos, Token: t.text, Reason: reason}
+}
+
+func (p *rangeParser) parseOr() (*OrNode, error) {
+	o := &OrNode{}
+	for {
+		a, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if len(o.Ranges) > 0 {
+			o.Separators = append(o.Separators, p.input[o.Ranges[len(o.Ranges)-1].end:a.pos])
+		}
+		o.Ranges = append(o.Ranges, a)
+		if p.advance().kind == tokenEOF {
+			o.pos, o.end = o.Ranges[0].pos, a.end
+			return o, nil
+		}
+	}
+}
+
+func (p *rangeParser) parseAnd() (*AndNode, error) {
+	// an empty alternative sits where the next token starts
+	a := &AndNode{pos: p.peek().pos, end: p.peek().pos}
+	for {
+		t := p.peek()
+		switch t.kind {
+		case tokenEOF, tokenOr:
+			return a, nil
+		case tokenHyphen:
+			if len(a.Nodes) == 0 {
+				return nil, p.fail(t, ReasonDanglingOperator)
+			}
+			return nil, p.fail(t, ReasonInvalid)
+		}
+		n, err := p.parseSimple()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind == tokenHyphen && len(a.Nodes) == 0 {
+			from, ok := bareVersion(n)
+			if !ok {
+				return nil, p.fail(p.peek(), ReasonInvalid)
+			}
+			n, err = p.parseHyphen(from)
+			if err != nil {
+				return nil, err
+			}
+			if t := p.peek(); t.kind != tokenEOF && t.kind != tokenOr {
+				// a hyphen range has to be the whole of its alternative
+				return nil, p.fail(t, ReasonInvalid)
+			}
+		}
+		if len(a.Nodes) > 0 {
+			a.Separators = append(a.Separators, p.input[a.end:n.Pos()])
+		} else {
+			a.pos = n.Pos()
+		}
+		a.Nodes = append(a.Nodes, n)
+		a.end = n.End()
+	}
+}
+
+// bareVersion returns the version of n if it was written without an operator
+func bareVersion(n Node) (PartialVersion, bool) {
+	switch n := n.(type) {
+	case *PrimitiveNode:
+		return n.Version, n.Op == ""
+	case *XRangeNode:
+		return n.Version, n.Op == ""
+	}
+	return PartialVersion{}, false
+}
+
+func (p *rangeParser) parseHyphen(from PartialVersion) (Node, error) {
+	hyphen := p.advance()
+	t := p.peek()
+	switch t.kind {
+	case tokenEOF, tokenOr:
+		return nil, p.fail(hyphen, ReasonDanglingOperator)
+	case tokenHyphen:
+		return nil, p.fail(t, ReasonInvalid)
+	case tokenOperator:
+		return nil, p.fail(t, ReasonBadOperator)
+	}
+	to, err := p.parseVersion(p.advance())
+	if err != nil {
+		return nil, err
+	}
+	return &HyphenNode{From: from, Hyphen: p.input[from.End():to.Pos()], To: to}, nil
+}
+
+func (p *rangeParser) parseSimple() (Node, error) {
+	t := p.advance()
+	start := t.pos
+	op := ""
+	if t.kind == tokenOperator {
+		if !containsString(rangeOperators, t.text) {
+			return nil, p.fail(t, ReasonBadOperator)
+		}
+		op = t.text
+		if p.peek().kind != tokenVersion {
+			return nil, p.fail(t, ReasonDanglingOperator)
+		}
+		t = p.advance()
+	}
+	v, err := p.parseVersion(t)
+	if err != nil {
+		return nil, err
+	}
+	space := p.input[start+len(op) : t.pos]
+	switch op {
+	case "^":
+		return &CaretNode{Space: space, Version: v, pos: start}, nil
+	case "~", "~>":
+		return &TildeNode{Op: op, Space: space, Version: v, pos: start}, nil
+	}
+	if v.scanned.parts == 3 {
+		return &PrimitiveNode{Op: op, Space: space, Version: v, pos: start}, nil
+	}
+	return &XRangeNode{Op: op, Space: space, Version: v, pos: start}, nil
+}
+
+func (p *rangeParser) parseVersion(t token) (PartialVersion, *RangeParseError) {
+	v := PartialVersion{Text: t.text, pos: t.pos}
+	scanned, err := v.scan(p.options)
+	if err != nil {
+		err.Input = p.input
+		return v, err
+	}
+	v.scanned = scanned
+	return v, nil
+}
+
+// scan reads the version's components, reusing what the parser found if
+// the text has not been changed since
+func (v PartialVersion) scan(options RangeOptions) (*scannedVersion, *RangeParseError) {
+	if v.scanned != nil && v.scanned.text == v.Text {
+		return v.scanned, nil
+	}
+	mode := scanPartial
+	if options.Loose {
+		mode |= scanLoose
+	}
+	parts, err := scanVersion(v.Text, 0, len(v.Text), mode)
+	if err != nil {
+		return nil, &RangeParseError{Offset: v.pos + err.Offset, Token: err.Token, Reason: err.Reason, Err: err}
+	}
+	return &scannedVersion{pos: v.pos, text: v.Text, versionParts: parts, includePrerelease: options.IncludePrerelease}, nil
+}
+
+func (n *OrNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	set := comparatorSet{}
+	for _, a := range n.Ranges {
+		c, err := a.desugar(options)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, c...)
+	}
+	return set, nil
+}
+
+func (n *AndNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	if len(n.Nodes) == 0 {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	set := comparatorSet{{}}
+	for _, node := range n.Nodes {
+		c, err := node.desugar(options)
+		if err != nil {
+			return nil, err
+		}
+		set = intersectSets(set, c)
+	}
+	return set, nil
+}
+
+// intersectSets combines every alternative of a with every alternative of
+// b, so the result matches what both match
+func intersectSets(a, b comparatorSet) comparatorSet {
+	o := comparatorSet{}
+	for _, x := range a {
+		for _, y := range b {
+			o = append(o, append(append(comparators{}, x...), y...))
+		}
+	}
+	return o
+}
+
+func (n *PrimitiveNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	return desugarXRange(n.Op, n.Version, options)
+}
+
+func (n *XRangeNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	return desugarXRange(n.Op, n.Version, options)
+}
+
+func desugarXRange(op string, pv PartialVersion, options RangeOptions) (comparatorSet, *RangeParseError) {
+	v, err := pv.scan(options)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(primitiveOperators, op) {
+		return nil, &RangeParseError{Offset: pv.pos, Token: op, Reason: ReasonBadOperator}
+	}
+	return v.xRange(op)
+}
+
+// xRange desugars a comparison against a version that may have wildcards
+func (v *scannedVersion) xRange(op string) (comparatorSet, *RangeParseError) {
+	if v.parts == 3 {
+		return comparatorSet{{newComparator(op, v.full())}}, nil
+	}
+	if op == "=" {
+		op = ""
+	}
+	if v.parts == 0 {
+		if op == ">" || op == "<" {
+			// nothing is allowed
+			return comparatorSet{{newComparator("<", minimumVersion)}}, nil
+		}
+		// nothing is forbidden
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	switch op {
+	case "":
+		next, err := v.next()
+		if err != nil {
+			return nil, err
+		}
+		return comparatorSet{{newComparator(">=", v.start(v.floor())), newComparator("<", next)}}, nil
+	case ">":
+		// >1 is >=2.0.0 and >1.2 is >=1.3.0
+		next, err := v.next()
+		if err != nil {
+			return nil, err
+		}
+		return comparatorSet{{newComparator(">=", next)}}, nil
+	case "<=":
+		// <=1.2.x is <1.3.0 since any 1.2.x should pass, and <=1.x is <2.0.0
+		next, err := v.next()
+		if err != nil {
+			return nil, err
+		}
+		return comparatorSet{{newComparator("<", next)}}, nil
+	}
+	return comparatorSet{{newComparator(op, v.start(v.floor()))}}, nil
+}
+
+func (n *CaretNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	v, err := n.Version.scan(options)
+	if err != nil {
+		return nil, err
+	}
+	return v.caret()
+}
+
+func (v *scannedVersion) caret() (comparatorSet, *RangeParseError) {
+	if v.parts == 0 {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	// ^ allows changes to the right of the first non-zero component, or of
+	// the last given one if they are all zero
+	significant := 1
+	for significant < v.parts && v.component(significant-1) == 0 {
+		significant++
+	}
+	upper, err := v.bump(significant)
+	if err != nil {
+		return nil, err
+	}
+	return comparatorSet{{newComparator(">=", v.lower()), newComparator("<", upper)}}, nil
+}
+
+func (n *TildeNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	if n.Op != "~" && n.Op != "~>" {
+		return nil, &RangeParseError{Offset: n.pos, Token: n.Op, Reason: ReasonBadOperator}
+	}
+	v, err := n.Version.scan(options)
+	if err != nil {
+		return nil, err
+	}
+	return v.tilde()
+}
+
+func (v *scannedVersion) tilde() (comparatorSet, *RangeParseError) {
+	if v.parts == 0 {
+		return comparatorSet{{anyComparator()}}, nil
+	}
+	// ~1.2.3 is >=1.2.3 <1.3.0 and ~1 is >=1.0.0 <2.0.0
+	significant := 2
+	if v.parts == 1 {
+		significant = 1
+	}
+	upper, err := v.bump(significant)
+	if err != nil {
+		return nil, err
+	}
+	return comparatorSet{{newComparator(">=", v.lower()), newComparator("<", upper)}}, nil
+}
+
+func (n *HyphenNode) desugar(options RangeOptions) (comparatorSet, *RangeParseError) {
+	from, err := n.From.scan(options)
+	if err != nil {
+		return nil, err
+	}
+	to, err := n.To.scan(options)
+	if err != nil {
+		return nil, err
+	}
+	return hyphen(from, to)
+}
+
+// hyphen desugars the inclusive range from - to
+func hyphen(from, to *scannedVersion) (comparatorSet, *RangeParseError) {
+	o := comparators{}
+	if from.parts == 3 {
+		o = append(o, newComparator(">=", from.start(from.full())))
+	} else if from.parts > 0 {
+		o = append(o, newComparator(">=", from.start(from.floor())))
+	}
+	if to.parts == 3 {
+		v := to.full()
+		if len(v.Prerelease) > 0 {
+			v.Build = []string{}
+		}
+		o = append(o, newComparator("<=", v))
+	} else if to.parts > 0 {
+		next, err := to.next()
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, newComparator("<", next))
+	}
+	if len(o) == 0 {
+		o = append(o, anyComparator())
+	}
+	return comparatorSet{o}, nil
+}
+
+// scannedVersion is a PartialVersion whose text has been scanned
+type scannedVersion struct {
+	pos  int
+	text string
+	versionParts
+	// includePrerelease makes the bounds it desugars to take in
+	// prereleases the way node-semver does
+	includePrerelease bool
+}
+
+// start returns o for use as the first version of a desugared bound. With
+// IncludePrerelease that is o-0, so 1.2.x takes in 1.2.0-beta and <1.3.0
+// leaves out 1.3.0-beta.
+func (v *scannedVersion) start(o *Version) *Version {
+	if v.includePrerelease && len(o.Prerelease) == 0 {
+		o.Prerelease = []string{"0"}
+	}
+	return o
+}
+
+func (v *scannedVersion) component(i int) uint64 {
+	return [3]uint64{v.major, v.minor, v.patch}[i]
+}
+
+// full returns the version as written, including prerelease and build
+func (v *scannedVersion) full() *Version {
+	return &Version{
+		Major:      v.major,
+		Minor:      v.minor,
+		Patch:      v.patch,
+		Prerelease: scannedPrerelease(v.text, v.versionParts),
+		Build:      splitIdentifiers(v.text, v.build),
+	}
+}
+
+// lower returns the version without build, with wildcards replaced by 0
+func (v *scannedVersion) lower() *Version {
+	if v.parts < 3 {
+		return v.start(v.floor())
+	}
+	o := v.full()
+	o.Build = []string{}
+	return o
+}
+
+// floor returns the lowest release the given components allow, so 1.2.x
+// gives 1.2.0
+func (v *scannedVersion) floor() *Version {
+	o := &Version{Prerelease: []string{}, Build: []string{}}
+	nums := [3]*uint64{&o.Major, &o.Minor, &o.Patch}
+	for i := 0; i < v.parts; i++ {
+		*nums[i] = v.component(i)
+	}
+	return o
+}
+
+// next returns the first release past every version the given components
+// allow, so 1.2.x gives 1.3.0 and 1.x gives 2.0.0
+func (v *scannedVersion) next() (*Version, *RangeParseError) {
+	return v.bump(v.parts)
+}
+
+// bump returns the release with the component at position n-1 incremented
+// and everything after it zeroed
+func (v *scannedVersion) bump(n int) (*Version, *RangeParseError) {
+	o := &Version{Prerelease: []string{}, Build: []string{}}
+	nums := [3]*uint64{&o.Major, &o.Minor, &o.Patch}
+	for i := 0; i < n; i++ {
+		*nums[i] = v.component(i)
+	}
+	if *nums[n-1] == maxComponent {
+		return nil, &RangeParseError{Offset: v.pos, Token: v.text, Reason: ReasonOverflow}
+	}
+	*nums[n-1]++
+	return v.start(o), nil
+}
+
+func newComparator(op string, v *Version) *comparator {
+	c := &comparator{version: v}
+	switch op {
+	case "", "=":
+		c.eq = true
+	case ">":
+		c.gt = true
+	case ">=":
+		c.gte = true
+	case "<":
+		c.lt = true
+	case "<=":
+		c.lte = true
+	}
+	return c
+}
+
+// anyComparator matches every version
+func anyComparator() *comparator {
+	return &comparator{eq: true, version: &Version{empty: true}}
+}
+
+func containsString(list []string, s string) bool {
+	for _, i := range list {
+		if i == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Simplify returns the shortest expression matching the same versions as r
+// when parsed with its IncludePrerelease setting, using ^, ~, x-ranges and
+// hyphen ranges where they fit. It is never longer than String or
+// CanonicalString.
+func (r *Range) Simplify() string {
+	s := r.versions()
+	parts := s.releases
+	if !r.options.IncludePrerelease {
+		windows, _ := s.windows()
+		parts = joinWindows(joinReleases(s.releaseSpans()), windows)
+	}
+	o := []string{}
+	for _, i := range parts {
+		o = append(o, i.simplify(r.options.IncludePrerelease))
+	}
+	shortest := emptyRange
+	if len(o) > 0 {
+		shortest = strings.Join(o, " || ")
+	}
+	// the spellings above only see one interval at a time, so the range as
+	// written or its canonical form can still be shorter
+	options := RangeOptions{IncludePrerelease: r.options.IncludePrerelease}
+	for _, candidate := range []string{r.String(), r.CanonicalString()} {
+		if len(candidate) >= len(shortest) {
+			continue
+		}
+		if other, err := ParseRange(candidate, options); err == nil && other.versions().equal(s) {
+			shortest = candidate
+		}
+	}
+	return shortest
+}
+
+// joinReleases merges spans of releases with only prereleases between them,
+// which a range without IncludePrerelease does not match anyway, so 1.2.3 ||
+// 1.2.4 becomes 1.2.3 - 1.2.4
+func joinReleases(spans []Interval) []Interval {
+	o := []Interval{}
+	for _, i := range spans {
+		if n := len(o); n > 0 {
+			last, _ := releaseInterval(o[n-1])
+			next, _ := releaseInterval(i)
+			if last.Upper.Version != nil && next.Lower.Version != nil && last.Upper.Version.EQ(next.Lower.Version) {
+				o[n-1].Upper = i.Upper
+				continue
+			}
+		}
+		o = append(o, i)
+	}
+	return o
+}
+
+// joinWindows folds each prerelease window reaching up to a release into the
+// span of releases starting there, so ^1.2.3-beta stays a single interval
+func joinWindows(spans, windows []Interval) []Interval {
+	o := []Interval{}
+	for _, w := range windows {
+		joined := false
+		for n, i := range spans {
+			first := release(0, 0, 0)
+			if in, _ := releaseInterval(i); in.Lower.Version != nil {
+				first = in.Lower.Version
+			}
+			if u := w.Upper.Version; !w.Upper.Inclusive && len(u.Prerelease) == 0 && u.EQ(first) {
+				spans[n].Lower = w.Lower
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			o = append(o, w)
+		}
+	}
+	o = append(o, spans...)
+	sort.SliceStable(o, func(a, b int) bool {
+		return compareLower(o[a].Lower, o[b].Lower) < 0
+	})
+	return o
+}
+
+// simplify returns the shortest spelling that matches the same versions as
+// the comparators of i. Spellings desugar differently with
+// IncludePrerelease, so each is checked by parsing it back.
+func (i Interval) simplify(includePrerelease bool) string {
+	options := RangeOptions{IncludePrerelease: includePrerelease}
+	want := (&Range{set: comparatorSet{i.comparators()}, options: options}).versions()
+	variants := []Interval{i}
+	// undo what canonical did to bounds next to a window start, so a
+	// hyphen range like 1.2.3 - 1.2.5 can be tried again
+	if v := i.Lower.Version; v != nil && !i.Lower.Inclusive && len(v.Prerelease) == 0 && v.Patch < maxComponent {
+		variant := i
+		variant.Lower = Bound{Version: windowStart([3]uint64{v.Major, v.Minor, v.Patch + 1}), Inclusive: true}
+		variants = append(variants, variant)
+	}
+	for _, variant := range variants {
+		if v := variant.Upper.Version; v != nil && variant.Upper.Inclusive && len(v.Prerelease) == 0 && v.Patch < maxComponent {
+			variant.Upper = Bound{Version: windowStart([3]uint64{v.Major, v.Minor, v.Patch + 1})}
+			variants = append(variants, variant)
+		}
+	}
+	if includePrerelease {
+		// node-semver moves desugared bounds onto -0, so ^1.2.3 ends at
+		// <2.0.0-0 and 1.x starts at >=1.0.0-0
+		for _, variant := range variants {
+			if v := variant.Lower.Version; v != nil && isWindowStart(v) {
+				variant.Lower.Version = release(v.Major, v.Minor, v.Patch)
+				variants = append(variants, variant)
+			}
+		}
+		for _, variant := range variants {
+			if v := variant.Upper.Version; v != nil && isWindowStart(v) {
+				variant.Upper.Version = release(v.Major, v.Minor, v.Patch)
+				variants = append(variants, variant)
+			}
+		}
+	}
+	shortest := ""
+	for _, variant := range variants {
+		for _, candidate := range variant.spellings() {
+			if shortest != "" && len(candidate) >= len(shortest) {
+				continue
+			}
+			if r, err := ParseRange(candidate, options); err == nil && r.versions().equal(want) {
+				shortest = candidate
+			}
+		}
+	}
+	if shortest == "" {
+		return intervalString(i)
+	}
+	return shortest
+}
+
+// isWindowStart returns true if v is the lowest prerelease of its
+// major.minor.patch
+func isWindowStart(v *Version) bool {
+	return len(v.Prerelease) == 1 && v.Prerelease[0] == "0"
+}
+
+// spellings lists the forms that desugar to exactly this interval, most
+// idiomatic first so ties keep the friendlier one.
+func (i Interval) spellings() []string {
+	l, u := i.Lower.Version, i.Upper.Version
+	if l == nil && u == nil {
+		return []string{"*"}
+	}
+	if l == nil {
+		if i.Upper.Inclusive {
+			return []string{"<=" + plain(u)}
+		}
+		return []string{"<" + plain(u)}
+	}
+	if u == nil {
+		if i.Lower.Inclusive {
+			return []string{">=" + plain(l)}
+		}
+		return []string{">" + plain(l)}
+	}
+	if i.Lower.Inclusive && i.Upper.Inclusive && l.EQ(u) {
+		return []string{plain(l)}
+	}
+	o := []string{}
+	if !i.Lower.Inclusive || i.Upper.Inclusive || len(u.Prerelease) > 0 {
+		if i.Lower.Inclusive && i.Upper.Inclusive {
+			o = append(o, plain(l)+" - "+plain(u))
+		}
+		return append(o, i.comparatorSpelling())
+	}
+	nextMajor := u.Major == l.Major+1 && u.Minor == 0 && u.Patch == 0
+	nextMinor := u.Major == l.Major && u.Minor == l.Minor+1 && u.Patch == 0
+	nextPatch := u.Major == l.Major && u.Minor == l.Minor && u.Patch == l.Patch+1
+	if len(l.Prerelease) == 0 {
+		if nextMajor && l.Minor == 0 && l.Patch == 0 {
+			o = append(o, fmt.Sprintf("%d.x", l.Major))
+		}
+		if nextMinor && l.Patch == 0 {
+			o = append(o, fmt.Sprintf("%d.%d.x", l.Major, l.Minor))
+		}
+	}
+	if nextMajor && l.Major > 0 || nextMinor && l.Major == 0 && l.Minor > 0 || nextPatch && l.Major == 0 && l.Minor == 0 {
+		o = append(o, "^"+plain(l))
+	}
+	if nextMinor {
+		o = append(o, "~"+plain(l))
+	}
+	if l.Major == u.Major && l.Minor == u.Minor && l.Patch == u.Patch {
+		// a window of prereleases, which no x-range reaches
+		return append(o, i.comparatorSpelling())
+	}
+	if u.Minor == 0 && u.Patch == 0 && u.Major > 0 {
+		o = append(o, fmt.Sprintf("%s - %d.x", plain(l), u.Major-1))
+	}
+	if u.Patch == 0 && u.Minor > 0 {
+		o = append(o, fmt.Sprintf("%s - %d.%d.x", plain(l), u.Major, u.Minor-1))
+	}
+	return append(o, i.comparatorSpelling())
+}
+
+func (i Interval) comparatorSpelling() string {
+	o := []string{}
+	if i.Lower.Inclusive {
+		o = append(o, ">="+plain(i.Lower.Version))
+	} else {
+		o = append(o, ">"+plain(i.Lower.Version))
+	}
+	if i.Upper.Inclusive {
+		o = append(o, "<="+plain(i.Upper.Version))
+	} else {
+		o = append(o, "<"+plain(i.Upper.Version))
+	}
+	return strings.Join(o, " ")
+}
+
+// plain prints v without build metadata, which never affects matching.
+func plain(v *Version) string {
+	o := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		o = o + "-" + strings.Join(v.Prerelease, ".")
+	}
+	return o
+}
@@ -0,0 +1,157 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Value is an immutable version. Its parts cannot be changed once made, so
+// it is safe to share between goroutines, and two Values are == exactly when
+// they print the same, which makes it usable as a map key. Build metadata
+// takes part in ==; key on WithoutBuild to follow precedence instead. The
+// zero Value is 0.0.0.
+type Value struct {
+	major, minor, patch uint64
+	// prerelease and build hold the identifiers joined by dots
+	prerelease, build string
+	empty             bool
+}
+
+// Any is the wildcard as a Value, which Parse gives for blank input. See
+// Version.IsWildcard.
+var Any = Value{empty: true}
+
+// ParseValue parses raw as Parse does and returns it as a Value
+func ParseValue(raw string) (Value, error) {
+	v, err := Parse(raw)
+	if err != nil {
+		return Value{}, err
+	}
+	return v.Value(), nil
+}
+
+// Value returns an immutable copy of the version
+func (this *Version) Value() Value {
+	return Value{
+		major:      this.Major,
+		minor:      this.Minor,
+		patch:      this.Patch,
+		prerelease: strings.Join(this.Prerelease, "."),
+		build:      strings.Join(this.Build, "."),
+		empty:      this.empty,
+	}
+}
+
+// Version returns the value as a new *Version for the rest of the package.
+// Changing it does not change the Value.
+func (this Value) Version() *Version {
+	return &Version{
+		Major:      this.major,
+		Minor:      this.minor,
+		Patch:      this.patch,
+		Prerelease: this.Prerelease(),
+		Build:      this.Build(),
+		empty:      this.empty,
+	}
+}
+
+// IsWildcard returns true if the value is Any
+func (this Value) IsWildcard() bool { return this.empty }
+
+func (this Value) Major() uint64 { return this.major }
+func (this Value) Minor() uint64 { return this.minor }
+func (this Value) Patch() uint64 { return this.patch }
+
+// Prerelease returns a new slice of the prerelease identifiers
+func (this Value) Prerelease() []string {
+	return removeEmpty(strings.Split(this.prerelease, "."))
+}
+
+// Build returns a new slice of the build identifiers
+func (this Value) Build() []string {
+	return removeEmpty(strings.Split(this.build, "."))
+}
+
+func (this Value) String() string {
+	return this.Version().String()
+}
+
+// Compare returns -1, 0 or 1 if this value is less than, equal to or
+// greater than b. Build metadata is ignored.
+func (this Value) Compare(b Value) int {
+	return this.Version().compare(b.Version())
+}
+
+// WithPrerelease returns a copy with its prerelease identifiers replaced by
+// identifiers. Passing none gives the release. It fails for Any.
+func (this Value) WithPrerelease(identifiers ...string) (Value, error) {
+	if this.empty {
+		return Value{}, fmt.Errorf("invalid prerelease: the wildcard has none")
+	}
+	if err := checkIdentifiers(identifiers, true); err != nil {
+		return Value{}, err
+	}
+	this.prerelease = strings.Join(identifiers, ".")
+	return this, nil
+}
+
+// WithBuild returns a copy with its build identifiers replaced by
+// identifiers. It fails for Any.
+func (this Value) WithBuild(identifiers ...string) (Value, error) {
+	if this.empty {
+		return Value{}, fmt.Errorf("invalid build: the wildcard has none")
+	}
+	if err := checkIdentifiers(identifiers, false); err != nil {
+		return Value{}, err
+	}
+	this.build = strings.Join(identifiers, ".")
+	return this, nil
+}
+
+// WithoutBuild returns a copy with no build metadata, which is == to every
+// other build of the same version
+func (this Value) WithoutBuild() Value {
+	this.build = ""
+	return this
+}
+
+func (this Value) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+func (this *Value) UnmarshalText(b []byte) error {
+	var v Version
+	if err := v.UnmarshalText(b); err != nil {
+		return err
+	}
+	*this = v.Value()
+	return nil
+}
+
+// checkIdentifiers returns an error unless each of identifiers is a single
+// prerelease or build identifier. Offsets in the error are into the
+// identifiers joined by dots, as they would be written.
+func checkIdentifiers(identifiers []string, prerelease bool) error {
+	input := strings.Join(identifiers, ".")
+	pos := 0
+	for _, id := range identifiers {
+		fail := func(reason Reason, offset int, token string) error {
+			return &ParseError{Input: input, Offset: pos + offset, Token: token, Reason: reason}
+		}
+		if id == "" {
+			return fail(ReasonEmptyIdentifier, 0, "")
+		}
+		numeric := true
+		for i := 0; i < len(id); i++ {
+			if !isIdentifierChar(id[i]) {
+				return fail(ReasonBadCharacter, i, id[i:runeEnd(id, i, len(id))])
+			}
+			numeric = numeric && isDigit(id[i])
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return fail(ReasonLeadingZero, 0, id)
+		}
+		pos += len(id) + 1
+	}
+	return nil
+}
@@ -3,29 +3,19 @@ package semver
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-var numericIdentifier = `0|[1-9]\d*`
-var nonNumericIdentifier = `\d*[a-zA-Z-][a-zA-Z0-9-]*`
-var buildIdentifier = `[0-9A-Za-z-]+`
-var build = `(?:\+(` + buildIdentifier + `(?:\.` + buildIdentifier + `)*))`
-var mainVersion = `(` + numericIdentifier + `)\.(` + numericIdentifier + `)\.(` + numericIdentifier + `)`
-var prereleaseIdentifier = `(?:` + numericIdentifier + `|` + nonNumericIdentifier + `)`
-var prerelease = `(?:-(` + prereleaseIdentifier + `(?:\.` + prereleaseIdentifier + `)*))`
-var fullPlain = `v?` + mainVersion + prerelease + `?` + build + `?`
-var reMainVersion = regexp.MustCompile(mainVersion)
-var reFull = regexp.MustCompile(`^` + fullPlain + `$`)
-var reNumeric = regexp.MustCompile(`^[0-9]+$`)
+var reCoerce = regexp.MustCompile(`(?:^|[^\d])(\d{1,16})(?:\.(\d{1,16}))?(?:\.(\d{1,16}))?(?:$|[^\d])`)
 
 type Version struct {
-	Major      int
-	Minor      int
-	Patch      int
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
 	Prerelease []string
 	Build      []string
 	empty      bool
@@ -51,32 +41,80 @@ func MustParseArr(raw ...string) []*Version {
 	return versions
 }
 
+// Parse reads a SemVer version, allowing a leading v and whitespace around
+// it. Blank input gives the wildcard rather than an error, as it always has;
+// use ParseStrict to reject it.
 func Parse(raw string) (*Version, error) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return &Version{empty: true}, nil
+	return parse(raw, 0)
+}
+
+// ParseStrict is like Parse but fails on blank input instead of returning
+// the wildcard, for callers that need an actual version
+func ParseStrict(raw string) (*Version, error) {
+	v, err := Parse(raw)
+	if err != nil {
+		return nil, err
 	}
-	parts := make([]int, 3)
-	submatches := reFull.FindStringSubmatch(raw)
+	if v.empty {
+		start, _ := trimSpace(raw)
+		return nil, &ParseError{Input: raw, Offset: start, Token: "", Reason: ReasonMissingComponent}
+	}
+	return v, nil
+}
+
+// ParseBytes is like Parse but reads from a byte slice without converting
+// all of it to a string first
+func ParseBytes(raw []byte) (*Version, error) {
+	return parse(raw, 0)
+}
+
+// ParseLoose parses versions that are not strict SemVer but are still
+// unambiguous, such as =1.2.3, v 1.2.3, 1.2.3beta and 01.02.03
+func ParseLoose(raw string) (*Version, error) {
+	return parse(raw, scanLoose)
+}
+
+// Coerce pulls the first version-looking run of numbers out of raw, so
+// v1.2 becomes 1.2.0 and release-2.4.1-final becomes 2.4.1. Prerelease and
+// build identifiers are dropped.
+func Coerce(raw string) (*Version, error) {
+	submatches := reCoerce.FindStringSubmatch(raw)
 	if len(submatches) == 0 {
-		return nil, errors.New("invalid version: " + raw)
+		return nil, &ParseError{Input: raw, Offset: 0, Token: raw, Reason: ReasonInvalid}
+	}
+	parts := submatches[1:4]
+	for i := range parts {
+		if parts[i] == "" {
+			parts[i] = "0"
+		}
 	}
-	for i, s := range submatches[1:4] {
+	return newVersion(raw, parts, "", "")
+}
+
+func newVersion(input string, parts []string, prerelease, build string) (*Version, error) {
+	nums := make([]uint64, 3)
+	for i, s := range parts {
 		var err error
-		parts[i], err = strconv.Atoi(s)
+		nums[i], err = strconv.ParseUint(s, 10, 64)
 		if err != nil {
-			return nil, err
+			return nil, &ParseError{Input: input, Offset: strings.Index(input, s), Token: s, Reason: ReasonOverflow}
 		}
 	}
 	return &Version{
-		Major:      parts[0],
-		Minor:      parts[1],
-		Patch:      parts[2],
-		Prerelease: removeEmpty(strings.Split(submatches[4], ".")),
-		Build:      removeEmpty(strings.Split(submatches[5], ".")),
+		Major:      nums[0],
+		Minor:      nums[1],
+		Patch:      nums[2],
+		Prerelease: removeEmpty(strings.Split(prerelease, ".")),
+		Build:      removeEmpty(strings.Split(build, ".")),
 	}, nil
 }
 
+// IsWildcard returns true for the version Parse gives for blank input. It
+// prints as *, stands for no particular version and sorts below all others.
+func (this *Version) IsWildcard() bool {
+	return this.empty
+}
+
 func (this *Version) String() string {
 	if this.empty {
 		return "*"
@@ -100,17 +138,34 @@ func (this *Version) MarshalJSON() ([]byte, error) {
 }
 
 func (this *Version) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	return this.UnmarshalText([]byte(raw))
+}
+
+func (this *Version) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+func (this *Version) UnmarshalText(b []byte) error {
+	if strings.TrimSpace(string(b)) == "*" {
+		*this = Version{empty: true}
+		return nil
+	}
 	v, err := Parse(string(b))
 	if err != nil {
 		return err
 	}
-	this.Major = v.Major
-	this.Minor = v.Minor
-	this.Patch = v.Patch
+	*this = *v
 	return nil
 }
 
-func compare(a, b int) int {
+func compare[T int | uint64](a, b T) int {
 	if a < b {
 		return -1
 	}
@@ -158,6 +213,16 @@ func (a *Version) comparePre(b *Version) int {
 	}
 }
 func (a *Version) compare(b *Version) int {
+	if a.empty || b.empty {
+		// the wildcard is only equal to itself and below everything else
+		if a.empty && b.empty {
+			return 0
+		}
+		if a.empty {
+			return -1
+		}
+		return 1
+	}
 	var c int
 	c = a.compareMajor(b)
 	if c != 0 {
@@ -178,6 +243,50 @@ func (a *Version) compare(b *Version) int {
 	return 0
 }
 
+// Diff returns the level at which a and b differ, or an empty ReleaseType
+// if they are equal. If only one of them is the wildcard it returns Wildcard.
+func Diff(a, b *Version) ReleaseType {
+	if a.empty != b.empty {
+		return Wildcard
+	}
+	c := a.compare(b)
+	if c == 0 {
+		return ""
+	}
+	high, low := a, b
+	if c < 0 {
+		high, low = b, a
+	}
+	highHasPre := len(high.Prerelease) > 0
+	lowHasPre := len(low.Prerelease) > 0
+	if lowHasPre && !highHasPre {
+		// going from a prerelease to its release is the bump that release made
+		if low.Minor == 0 && low.Patch == 0 {
+			return Major
+		}
+		if low.compareMajor(high) == 0 && low.compareMinor(high) == 0 && low.comparePatch(high) == 0 {
+			if low.Minor != 0 && low.Patch == 0 {
+				return Minor
+			}
+			return Patch
+		}
+	}
+	prefix := ""
+	if highHasPre {
+		prefix = "pre"
+	}
+	if a.compareMajor(b) != 0 {
+		return ReleaseType(prefix + string(Major))
+	}
+	if a.compareMinor(b) != 0 {
+		return ReleaseType(prefix + string(Minor))
+	}
+	if a.comparePatch(b) != 0 {
+		return ReleaseType(prefix + string(Patch))
+	}
+	return Prerelease
+}
+
 // LT returns true is given version is less than this one
 func (a *Version) LT(b *Version) bool {
 	return a.compare(b) < 0
@@ -195,21 +304,109 @@ func (a *Version) EQ(b *Version) bool {
 	return a.compare(b) == 0
 }
 
+// CompareBuild compares like LT/GT but breaks ties by build metadata, so
+// 1.0.0 < 1.0.0+a < 1.0.0+b
+func (a *Version) CompareBuild(b *Version) int {
+	c := a.compare(b)
+	if c != 0 {
+		return c
+	}
+	var i = 0
+	for {
+		if len(a.Build)-1 < i && len(b.Build)-1 < i {
+			return 0
+		}
+		if len(a.Build)-1 < i {
+			return -1
+		}
+		if len(b.Build)-1 < i {
+			return 1
+		}
+		if a.Build[i] != b.Build[i] {
+			return compareIdentifiers(a.Build[i], b.Build[i])
+		}
+		i++
+	}
+}
+
+// StrictEqual returns true if both versions are equal including build metadata
+func (a *Version) StrictEqual(b *Version) bool {
+	return a.CompareBuild(b) == 0
+}
+
+// Compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// Build metadata is ignored. It can be passed straight to slices.SortFunc.
+func Compare(a, b *Version) int {
+	return a.compare(b)
+}
+
+// Compare returns -1, 0 or 1 if this version is less than, equal to or
+// greater than b
+func (a *Version) Compare(b *Version) int {
+	return a.compare(b)
+}
+
 type Versions []*Version
 
 func (v Versions) Len() int {
 	return len(v)
 }
 func (v Versions) Less(a, b int) bool {
-	return v[a].LT(v[b])
+	return v[a].compare(v[b]) < 0
 }
 func (v Versions) Swap(a, b int) {
 	v[a], v[b] = v[b], v[a]
 }
 
+// Max returns the highest version, or nil if there are none
+func (v Versions) Max() *Version {
+	var max *Version
+	for _, i := range v {
+		if max == nil || i.compare(max) > 0 {
+			max = i
+		}
+	}
+	return max
+}
+
+// Min returns the lowest version, or nil if there are none
+func (v Versions) Min() *Version {
+	var min *Version
+	for _, i := range v {
+		if min == nil || i.compare(min) < 0 {
+			min = i
+		}
+	}
+	return min
+}
+
+// Sort sorts versions from lowest to highest in place
+func Sort(versions Versions) {
+	sort.Sort(versions)
+}
+
+// SortDesc sorts versions from highest to lowest in place
+func SortDesc(versions Versions) {
+	sort.Sort(sort.Reverse(versions))
+}
+
+// VersionsByBuild sorts like Versions but orders builds of the same
+// release by their build metadata
+type VersionsByBuild []*Version
+
+func (v VersionsByBuild) Len() int {
+	return len(v)
+}
+func (v VersionsByBuild) Less(a, b int) bool {
+	return v[a].CompareBuild(v[b]) < 0
+}
+func (v VersionsByBuild) Swap(a, b int) {
+	v[a], v[b] = v[b], v[a]
+}
+
 func compareIdentifiers(a, b string) int {
-	anum := reNumeric.MatchString(a)
-	bnum := reNumeric.MatchString(b)
+	anum := isNumeric(a)
+	bnum := isNumeric(b)
 
 	if anum && !bnum {
 		return -1
@@ -218,13 +415,7 @@ func compareIdentifiers(a, b string) int {
 		return 1
 	}
 	if anum && bnum {
-		if atoi(a) < atoi(b) {
-			return -1
-		}
-		if atoi(a) > atoi(b) {
-			return 1
-		}
-		return 0
+		return compareDigits(a, b)
 	}
 	if a < b {
 		return -1
@@ -235,6 +426,29 @@ func compareIdentifiers(a, b string) int {
 	return 0
 }
 
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareDigits compares two decimal strings by value without converting
+// them, which is faster and cannot overflow
+func compareDigits(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if c := compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
 func removeEmpty(s []string) []string {
 	o := []string{}
 	for _, s := range s {